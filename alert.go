@@ -0,0 +1,434 @@
+package coincap
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// AlertKind type.
+type AlertKind uint8
+
+// Alert rule kinds.
+const (
+	CrossAbove    AlertKind = iota // price crosses above Value
+	CrossBelow                     // price crosses below Value
+	PercentChange                  // price changes by Value percent within Window (negative Value means a drop)
+	Volatility                     // standard deviation of price returns within Window exceeds Value percent
+	Change24Hr                     // 24 hour change exceeds Value percent in either direction (reference prices are fetched by Run or set by SetAssets)
+)
+
+func (k AlertKind) String() string {
+	switch k {
+	case CrossAbove:
+		return "cross_above"
+	case CrossBelow:
+		return "cross_below"
+	case PercentChange:
+		return "percent_change"
+	case Volatility:
+		return "volatility"
+	case Change24Hr:
+		return "change_24hr"
+	}
+	return "unknown"
+}
+
+// MarshalText is encoding.TextMarshaler implementation.
+func (k AlertKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// AlertRule declares an alert condition for an asset.
+type AlertRule struct {
+	Name       string
+	Asset      string        // asset ID as in the Prices stream
+	Kind       AlertKind     // condition kind
+	Value      float64       // price level or percent depending on the kind
+	Window     time.Duration // time window for PercentChange and Volatility
+	Hysteresis float64       // percent of Value the measure must retreat by to re-arm the rule
+	Cooldown   time.Duration // minimum time between two alerts of the rule
+}
+
+// Alert is a triggered alert rule.
+type Alert struct {
+	Rule    AlertRule `json:"rule"`
+	Asset   string    `json:"asset"`
+	Price   float64   `json:"price"`   // price that triggered the alert
+	Measure float64   `json:"measure"` // measured value compared against the rule Value
+	Time    time.Time `json:"time"`
+}
+
+func (a Alert) String() string {
+	s := a.Asset + " " + a.Rule.Kind.String() + " " + strconv.FormatFloat(a.Rule.Value, 'f', -1, 64) +
+		": price " + strconv.FormatFloat(a.Price, 'f', -1, 64) +
+		", measure " + strconv.FormatFloat(a.Measure, 'f', -1, 64)
+	if a.Rule.Name != "" {
+		s = a.Rule.Name + ": " + s
+	}
+	return s
+}
+
+// AlertSink receives triggered alerts.
+type AlertSink interface {
+	Notify(Alert) error
+}
+
+// AlertFunc is a callback alert sink.
+type AlertFunc func(Alert) error
+
+// Notify is AlertSink implementation.
+func (f AlertFunc) Notify(a Alert) error {
+	return f(a)
+}
+
+// AlertChan is a channel alert sink.
+// Alerts are dropped if the channel is not ready to receive.
+type AlertChan chan<- Alert
+
+// Notify is AlertSink implementation.
+func (c AlertChan) Notify(a Alert) error {
+	select {
+	case c <- a:
+		return nil
+	default:
+		return errors.New("alert channel is full")
+	}
+}
+
+// LogSink writes alerts to the logger at the INFO level.
+// slog.Default is used if Logger is nil.
+type LogSink struct {
+	Logger *slog.Logger
+}
+
+// Notify is AlertSink implementation.
+func (s LogSink) Notify(a Alert) error {
+	l := s.Logger
+	if l == nil {
+		l = slog.Default()
+	}
+	l.LogAttrs(context.Background(), slog.LevelInfo, "coincap alert",
+		slog.String("rule", a.Rule.Name),
+		slog.String("asset", a.Asset),
+		slog.String("kind", a.Rule.Kind.String()),
+		slog.Float64("value", a.Rule.Value),
+		slog.Float64("price", a.Price),
+		slog.Float64("measure", a.Measure))
+	return nil
+}
+
+// WebhookSink posts alerts as JSON to the URL.
+// http.DefaultClient is used if Client is nil.
+type WebhookSink struct {
+	URL    string
+	Client *http.Client
+}
+
+// Notify is AlertSink implementation.
+func (s WebhookSink) Notify(a Alert) error {
+	body, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	c := s.Client
+	if c == nil {
+		c = http.DefaultClient
+	}
+	resp, err := c.Post(s.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	// drain the body so the connection can be reused
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return errors.New("webhook (" + resp.Status + ")")
+	}
+	return nil
+}
+
+type pricePoint struct {
+	price float64
+	time  time.Time
+}
+
+type ruleState struct {
+	rule   AlertRule
+	init   bool
+	armed  bool
+	last   time.Time
+	hyster float64 // re-arm threshold of the measure
+}
+
+// AlertEngine evaluates alert rules against price updates
+// and dispatches triggered alerts to the sinks.
+//
+// It is safe for concurrent use.
+type AlertEngine struct {
+	// OnError is called when a sink fails to receive an alert.
+	OnError func(AlertSink, Alert, error)
+
+	mu      sync.Mutex
+	rules   map[string][]*ruleState // by asset
+	history map[string][]pricePoint // by asset
+	windows map[string]time.Duration
+	open24  map[string]float64 // price 24 hours ago by asset
+	sinks   []AlertSink
+}
+
+// NewAlertEngine creates an alert engine.
+func NewAlertEngine(rules []AlertRule, sinks ...AlertSink) *AlertEngine {
+	e := &AlertEngine{
+		rules:   make(map[string][]*ruleState),
+		history: make(map[string][]pricePoint),
+		windows: make(map[string]time.Duration),
+		open24:  make(map[string]float64),
+		sinks:   sinks,
+	}
+	for _, r := range rules {
+		e.AddRule(r)
+	}
+	return e
+}
+
+// AddRule adds the rule to the engine.
+func (e *AlertEngine) AddRule(r AlertRule) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	st := &ruleState{rule: r, armed: true}
+	st.hyster = math.Abs(r.Value) * (1 - r.Hysteresis/100)
+	switch r.Kind {
+	case CrossAbove:
+		st.hyster = r.Value * (1 - r.Hysteresis/100)
+	case CrossBelow:
+		st.hyster = r.Value * (1 + r.Hysteresis/100)
+	}
+	e.rules[r.Asset] = append(e.rules[r.Asset], st)
+	if r.Window > e.windows[r.Asset] {
+		e.windows[r.Asset] = r.Window
+	}
+}
+
+// SetAssets sets the 24 hour reference prices used by Change24Hr rules.
+// Change24Hr rules never fire for assets without a reference price.
+// Run keeps them current; callers of Process must refresh them
+// periodically with recent Assets data.
+func (e *AlertEngine) SetAssets(assets []Asset) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, a := range assets {
+		if a.PriceUsd > 0 && a.ChangePercent24Hr > -100 {
+			e.open24[a.ID] = a.PriceUsd / (1 + a.ChangePercent24Hr/100)
+		}
+	}
+}
+
+// Process evaluates the rules against the prices and dispatches
+// the triggered alerts.
+func (e *AlertEngine) Process(prices map[string]float64, now time.Time) []Alert {
+	e.mu.Lock()
+	var alerts []Alert
+	for id, p := range prices {
+		rules, ok := e.rules[id]
+		if !ok {
+			continue
+		}
+		hist := e.record(id, p, now)
+		for _, st := range rules {
+			if a, ok := e.eval(st, hist, p, now); ok {
+				alerts = append(alerts, a)
+			}
+		}
+	}
+	sinks := e.sinks
+	onError := e.OnError
+	e.mu.Unlock()
+
+	for _, a := range alerts {
+		for _, s := range sinks {
+			if err := s.Notify(a); err != nil && onError != nil {
+				onError(s, a, err)
+			}
+		}
+	}
+	return alerts
+}
+
+func (e *AlertEngine) record(id string, p float64, now time.Time) []pricePoint {
+	hist := append(e.history[id], pricePoint{p, now})
+	cut := 0
+	for cut < len(hist)-1 && now.Sub(hist[cut].time) > e.windows[id] {
+		cut++
+	}
+	hist = hist[cut:]
+	e.history[id] = hist
+	return hist
+}
+
+func (e *AlertEngine) measure(r *AlertRule, hist []pricePoint, p float64, now time.Time) (float64, bool) {
+	switch r.Kind {
+	case CrossAbove, CrossBelow:
+		return p, true
+	case PercentChange:
+		for _, pt := range hist {
+			if now.Sub(pt.time) <= r.Window && pt.price > 0 {
+				return (p - pt.price) / pt.price * 100, true
+			}
+		}
+	case Volatility:
+		return volatility(hist, now.Add(-r.Window))
+	case Change24Hr:
+		if open, ok := e.open24[r.Asset]; ok && open > 0 {
+			return (p - open) / open * 100, true
+		}
+	}
+	return 0, false
+}
+
+// volatility returns the standard deviation of price returns in percent.
+func volatility(hist []pricePoint, since time.Time) (float64, bool) {
+	var rets []float64
+	for i := 1; i < len(hist); i++ {
+		if hist[i-1].time.Before(since) || hist[i-1].price <= 0 {
+			continue
+		}
+		rets = append(rets, hist[i].price/hist[i-1].price-1)
+	}
+	if len(rets) < 2 {
+		return 0, false
+	}
+	var mean float64
+	for _, r := range rets {
+		mean += r
+	}
+	mean /= float64(len(rets))
+	var variance float64
+	for _, r := range rets {
+		variance += (r - mean) * (r - mean)
+	}
+	variance /= float64(len(rets) - 1)
+	return math.Sqrt(variance) * 100, true
+}
+
+func (e *AlertEngine) eval(st *ruleState, hist []pricePoint, p float64, now time.Time) (Alert, bool) {
+	r := &st.rule
+	m, ok := e.measure(r, hist, p, now)
+	if !ok {
+		return Alert{}, false
+	}
+
+	var hit, rearm bool
+	switch r.Kind {
+	case CrossAbove:
+		hit, rearm = m >= r.Value, m < st.hyster
+	case CrossBelow:
+		hit, rearm = m <= r.Value, m > st.hyster
+	case PercentChange:
+		if r.Value < 0 {
+			hit, rearm = m <= r.Value, -m < st.hyster
+		} else {
+			hit, rearm = m >= r.Value, m < st.hyster
+		}
+	default:
+		hit, rearm = math.Abs(m) >= math.Abs(r.Value), math.Abs(m) < st.hyster
+	}
+
+	// crossing rules require the price to be on the other side first.
+	if !st.init {
+		st.init = true
+		if hit && (r.Kind == CrossAbove || r.Kind == CrossBelow) {
+			st.armed = false
+			return Alert{}, false
+		}
+	}
+
+	if !st.armed {
+		if rearm {
+			st.armed = true
+		}
+		return Alert{}, false
+	}
+	if !hit || (r.Cooldown > 0 && now.Sub(st.last) < r.Cooldown) {
+		return Alert{}, false
+	}
+	st.armed = false
+	st.last = now
+	return Alert{Rule: *r, Asset: r.Asset, Price: p, Measure: m, Time: now}, true
+}
+
+// ErrNoAssetsClient is returned by Run if there are Change24Hr rules
+// but no client to fetch their reference prices.
+var ErrNoAssetsClient = errors.New("change_24hr rules require a client")
+
+// change24Assets returns the assets of the Change24Hr rules.
+func (e *AlertEngine) change24Assets() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var ids []string
+	for id, rules := range e.rules {
+		for _, st := range rules {
+			if st.rule.Kind == Change24Hr {
+				ids = append(ids, id)
+				break
+			}
+		}
+	}
+	return ids
+}
+
+func (e *AlertEngine) fetchAssets(c *Client, ids []string) error {
+	res, err := c.AssetsByIDs(ids, nil)
+	if err != nil {
+		return err
+	}
+	e.SetAssets(res.Assets)
+	return nil
+}
+
+// Run processes the prices stream until it stops
+// and returns the stream error.
+// If there are Change24Hr rules their reference prices are fetched with
+// the client before processing and then every refresh (1 minute if not
+// positive). The client may be nil only if there are no such rules.
+func (e *AlertEngine) Run(s *Stream[map[string]float64], c *Client, refresh time.Duration) error {
+	if ids := e.change24Assets(); len(ids) > 0 {
+		if c == nil {
+			return ErrNoAssetsClient
+		}
+		if err := e.fetchAssets(c, ids); err != nil {
+			return err
+		}
+		if refresh <= 0 {
+			refresh = time.Minute
+		}
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			ticker := time.NewTicker(refresh)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+				}
+				if err := e.fetchAssets(c, e.change24Assets()); err != nil {
+					logPollError(c.log, "alert assets", err)
+				}
+			}
+		}()
+	}
+	for prices := range s.DataChannel() {
+		e.Process(prices, time.Now())
+	}
+	return s.Err()
+}
@@ -0,0 +1,183 @@
+package coincap
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAlertEngine(t *testing.T) {
+	var got []Alert
+	e := NewAlertEngine([]AlertRule{
+		{Name: "btc-30k", Asset: "bitcoin", Kind: CrossAbove, Value: 30000, Hysteresis: 1},
+		{Name: "eth-drop", Asset: "ethereum", Kind: PercentChange, Value: -5, Window: time.Minute},
+	}, AlertFunc(func(a Alert) error {
+		got = append(got, a)
+		return nil
+	}))
+
+	start := time.Unix(0, 0)
+	steps := []struct {
+		prices map[string]float64
+		alerts int
+	}{
+		{map[string]float64{"bitcoin": 29000, "ethereum": 1000}, 0},
+		{map[string]float64{"bitcoin": 30100, "ethereum": 990}, 1},
+		{map[string]float64{"bitcoin": 29900, "ethereum": 960}, 0}, // within hysteresis
+		{map[string]float64{"bitcoin": 30200, "ethereum": 940}, 1}, // eth dropped 6%
+		{map[string]float64{"bitcoin": 29600, "ethereum": 930}, 0}, // btc re-armed
+		{map[string]float64{"bitcoin": 30001}, 1},
+	}
+	total := 0
+	for i, s := range steps {
+		alerts := e.Process(s.prices, start.Add(time.Duration(i)*time.Second))
+		if len(alerts) != s.alerts {
+			t.Fatalf("step %d: expected %d alerts, got %+v", i, s.alerts, alerts)
+		}
+		total += len(alerts)
+	}
+	if len(got) != total {
+		t.Fatalf("sink received %d alerts, want %d", len(got), total)
+	}
+	if got[1].Rule.Name != "eth-drop" || got[1].Measure > -5 {
+		t.Errorf("unexpected alert %+v", got[1])
+	}
+}
+
+// processSteps processes the prices at the offsets and returns the number
+// of alerts per step.
+func processSteps(e *AlertEngine, offsets []time.Duration, prices []float64) []int {
+	start := time.Unix(0, 0)
+	n := make([]int, len(prices))
+	for i, p := range prices {
+		n[i] = len(e.Process(map[string]float64{"bitcoin": p}, start.Add(offsets[i])))
+	}
+	return n
+}
+
+func seconds(n ...int) []time.Duration {
+	d := make([]time.Duration, len(n))
+	for i := range n {
+		d[i] = time.Duration(n[i]) * time.Second
+	}
+	return d
+}
+
+func TestAlertVolatility(t *testing.T) {
+	e := NewAlertEngine([]AlertRule{{Asset: "bitcoin", Kind: Volatility, Value: 1, Window: time.Minute}})
+	got := processSteps(e, seconds(0, 1, 2, 3), []float64{100, 100.1, 100.2, 103})
+	if got[0]+got[1]+got[2] != 0 || got[3] != 1 {
+		t.Errorf("unexpected alerts %v", got)
+	}
+
+	// old returns are outside the window.
+	e = NewAlertEngine([]AlertRule{{Asset: "bitcoin", Kind: Volatility, Value: 1, Window: 10 * time.Second}})
+	got = processSteps(e, seconds(0, 1, 2, 30, 31, 32), []float64{100, 105, 100, 100, 100.1, 100})
+	if got[2] != 1 || got[5] != 0 {
+		t.Errorf("unexpected alerts %v", got)
+	}
+}
+
+func TestAlertCooldown(t *testing.T) {
+	e := NewAlertEngine([]AlertRule{{Asset: "bitcoin", Kind: CrossAbove, Value: 10, Cooldown: time.Minute}})
+	got := processSteps(e, seconds(0, 1, 2, 3, 70), []float64{9, 11, 9, 11, 11})
+	want := []int{0, 1, 0, 0, 1}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got alerts %v, want %v", got, want)
+		}
+	}
+}
+
+func TestAlertChange24Hr(t *testing.T) {
+	rules := []AlertRule{{Asset: "bitcoin", Kind: Change24Hr, Value: 5}}
+	if err := NewAlertEngine(rules).Run(newStream[map[string]float64](), nil, 0); !errors.Is(err, ErrNoAssetsClient) {
+		t.Fatalf("expected ErrNoAssetsClient, got %v", err)
+	}
+
+	c := testClient(200, `{"data":[{"id":"bitcoin","priceUsd":"110","changePercent24Hr":"10"}],"timestamp":1}`)
+	alerts := make(chan Alert, 2)
+	e := NewAlertEngine(rules, AlertChan(alerts))
+	s := newStream[map[string]float64]()
+	go func() {
+		s.send(map[string]float64{"bitcoin": 104})
+		s.send(map[string]float64{"bitcoin": 106})
+		s.done(nil)
+	}()
+	if err := e.Run(s, &c, 0); err != nil {
+		t.Fatal(err)
+	}
+	if len(alerts) != 1 {
+		t.Fatalf("expected 1 alert, got %d", len(alerts))
+	}
+	if a := <-alerts; a.Price != 106 || a.Measure < 5.99 || a.Measure > 6.01 {
+		t.Errorf("unexpected alert %+v", a)
+	}
+}
+
+func TestAlertChan(t *testing.T) {
+	ch := make(chan Alert, 1)
+	if err := AlertChan(ch).Notify(Alert{Asset: "bitcoin"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := AlertChan(ch).Notify(Alert{Asset: "bitcoin"}); err == nil {
+		t.Error("expected an error for a full channel")
+	}
+	if a := <-ch; a.Asset != "bitcoin" {
+		t.Errorf("unexpected alert %+v", a)
+	}
+}
+
+func TestLogSink(t *testing.T) {
+	var buf bytes.Buffer
+	s := LogSink{Logger: slog.New(slog.NewTextHandler(&buf, nil))}
+	if err := s.Notify(Alert{Rule: AlertRule{Name: "btc", Kind: CrossAbove}, Asset: "bitcoin", Price: 2}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`msg="coincap alert"`, "rule=btc", "kind=cross_above", "price=2"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("log %q does not contain %q", buf.String(), want)
+		}
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+	var got struct {
+		Rule struct {
+			Kind string
+		} `json:"rule"`
+		Asset   string  `json:"asset"`
+		Measure float64 `json:"measure"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if got.Asset == "fail" {
+			http.Error(w, "failed", http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	s := WebhookSink{URL: srv.URL, Client: srv.Client()}
+	if err := s.Notify(Alert{Rule: AlertRule{Kind: Volatility}, Asset: "bitcoin", Measure: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if got.Asset != "bitcoin" || got.Rule.Kind != "volatility" || got.Measure != 3 {
+		t.Errorf("unexpected alert %+v", got)
+	}
+	if err := s.Notify(Alert{Asset: "fail"}); err == nil {
+		t.Error("expected an error for a failed webhook")
+	}
+}
@@ -1,6 +1,9 @@
 package coincap
 
-import "net/url"
+import (
+	"net/url"
+	"strings"
+)
 
 // Asset contains CoinCap asset data from exchanges.
 type Asset struct {
@@ -34,11 +37,13 @@ func (c *Client) AssetsSearch(search string, trim *TrimParams) ([]Asset, Timesta
 }
 
 // AssetsSearchByIDs returns a list of CoinCap assets.
+// It returns nil without requesting if ids is empty.
+// Use AssetsByIDs for long lists.
 func (c *Client) AssetsSearchByIDs(ids []string) ([]Asset, Timestamp, error) {
-	if ids == nil {
+	if len(ids) == 0 {
 		return nil, 0, nil
 	}
-	return request[[]Asset](c, "assets", url.Values{"ids": ids})
+	return request[[]Asset](c, "assets", url.Values{"ids": {strings.Join(ids, ",")}})
 }
 
 // AssetByID returns an asset by its ID.
@@ -0,0 +1,144 @@
+package coincap
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BatchOptions contains batch lookup parameters.
+type BatchOptions struct {
+	ChunkSize   int           // maximum number of IDs per request (100 if zero, at most 2000)
+	MaxQueryLen int           // maximum length of the joined IDs per request (1500 if zero)
+	Concurrency int           // maximum number of parallel requests (4 if zero)
+	Rate        time.Duration // minimum time between request starts (no limit if zero)
+}
+
+// BatchResult is the result of a batch lookup.
+type BatchResult struct {
+	Assets    []Asset   // found assets in the requested order
+	NotFound  []string  // requested IDs that were not found
+	Timestamp Timestamp // the earliest response timestamp
+}
+
+// chunkIDs splits the IDs into chunks limited by count and joined length.
+func chunkIDs(ids []string, size, maxLen int) [][]string {
+	var chunks [][]string
+	var cur []string
+	n := 0
+	for _, id := range ids {
+		if len(cur) > 0 && (len(cur) >= size || n+1+len(id) > maxLen) {
+			chunks = append(chunks, cur)
+			cur, n = nil, 0
+		}
+		if len(cur) > 0 {
+			n++ // comma
+		}
+		cur = append(cur, id)
+		n += len(id)
+	}
+	if len(cur) > 0 {
+		chunks = append(chunks, cur)
+	}
+	return chunks
+}
+
+// AssetsByIDs requests the assets splitting the IDs into chunks fetched
+// concurrently. Duplicate IDs are requested and returned once.
+// The first failed request cancels the remaining and in-flight ones.
+func (c *Client) AssetsByIDs(ids []string, opts *BatchOptions) (*BatchResult, error) {
+	var o BatchOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.ChunkSize <= 0 {
+		o.ChunkSize = 100
+	} else if o.ChunkSize > maxPage {
+		o.ChunkSize = maxPage
+	}
+	if o.MaxQueryLen <= 0 {
+		o.MaxQueryLen = 1500
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = 4
+	}
+
+	unique := make([]string, 0, len(ids))
+	seen := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; !ok && id != "" {
+			seen[id] = struct{}{}
+			unique = append(unique, id)
+		}
+	}
+	res := &BatchResult{Assets: make([]Asset, 0, len(unique))}
+	if len(unique) == 0 {
+		return res, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var (
+		mu     sync.Mutex
+		found  = make(map[string]Asset, len(unique))
+		err    error
+		wg     sync.WaitGroup
+		sem    = make(chan struct{}, o.Concurrency)
+		ticker *time.Ticker
+	)
+	if o.Rate > 0 {
+		ticker = time.NewTicker(o.Rate)
+		defer ticker.Stop()
+	}
+	for i, chunk := range chunkIDs(unique, o.ChunkSize, o.MaxQueryLen) {
+		mu.Lock()
+		failed := err != nil
+		mu.Unlock()
+		if failed {
+			break
+		}
+		if ticker != nil && i > 0 {
+			<-ticker.C
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(chunk []string) {
+			defer func() { <-sem; wg.Done() }()
+			q := url.Values{
+				"ids":   {strings.Join(chunk, ",")},
+				"limit": {utoa(uint(len(chunk)))},
+			}
+			assets, ts, e := requestContext[[]Asset](ctx, c, "assets", q)
+			mu.Lock()
+			defer mu.Unlock()
+			if e != nil {
+				if err == nil {
+					err = e
+					cancel()
+				}
+				return
+			}
+			if res.Timestamp == 0 || ts < res.Timestamp {
+				res.Timestamp = ts
+			}
+			for _, a := range assets {
+				found[a.ID] = a
+			}
+		}(chunk)
+	}
+	wg.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range unique {
+		if a, ok := found[id]; ok {
+			res.Assets = append(res.Assets, a)
+		} else {
+			res.NotFound = append(res.NotFound, id)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,92 @@
+package coincap
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestChunkIDs(t *testing.T) {
+	chunks := chunkIDs([]string{"aaa", "bb", "c", "dddd", "e"}, 3, 8)
+	want := [][]string{{"aaa", "bb", "c"}, {"dddd", "e"}}
+	if !reflect.DeepEqual(chunks, want) {
+		t.Errorf("chunkIDs = %v, want %v", chunks, want)
+	}
+}
+
+func TestAssetsByIDs(t *testing.T) {
+	var requests atomic.Int32
+	c := NewClient(&http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		requests.Add(1)
+		var assets []Asset
+		for _, id := range strings.Split(r.URL.Query().Get("ids"), ",") {
+			if !strings.HasPrefix(id, "missing") {
+				assets = append(assets, Asset{ID: id})
+			}
+		}
+		body, _ := json.Marshal(map[string]any{"data": assets, "timestamp": 1})
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(string(body))),
+			Request:    r,
+		}, nil
+	})}, nil)
+
+	var ids []string
+	for i := 0; i < 25; i++ {
+		ids = append(ids, "asset-"+strconv.Itoa(i))
+	}
+	ids = append(ids, "missing-1", "asset-3", "missing-2")
+
+	res, err := c.AssetsByIDs(ids, &BatchOptions{ChunkSize: 10, Concurrency: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests.Load() != 3 {
+		t.Errorf("expected 3 requests, got %d", requests.Load())
+	}
+	if len(res.Assets) != 25 || res.Assets[0].ID != "asset-0" || res.Assets[24].ID != "asset-24" {
+		t.Errorf("unexpected assets %v", res.Assets)
+	}
+	if !reflect.DeepEqual(res.NotFound, []string{"missing-1", "missing-2"}) {
+		t.Errorf("unexpected not found %v", res.NotFound)
+	}
+
+	if a, _, err := c.AssetsSearchByIDs([]string{}); a != nil || err != nil {
+		t.Errorf("empty ids must return nil, got %v, %v", a, err)
+	}
+}
+
+func TestAssetsByIDsCancel(t *testing.T) {
+	c := NewClient(&http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Query().Get("ids") != "fail" {
+			// in-flight request that must be canceled by the failure
+			select {
+			case <-r.Context().Done():
+				return nil, r.Context().Err()
+			case <-time.After(5 * time.Second):
+			}
+		}
+		return &http.Response{
+			StatusCode: http.StatusInternalServerError,
+			Status:     "500 Internal Server Error",
+			Body:       io.NopCloser(strings.NewReader("error")),
+			Request:    r,
+		}, nil
+	})}, nil)
+
+	start := time.Now()
+	_, err := c.AssetsByIDs([]string{"slow", "fail"}, &BatchOptions{ChunkSize: 1, Concurrency: 2})
+	if err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("expected the failed request error, got %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("in-flight request was not canceled")
+	}
+}
@@ -0,0 +1,38 @@
+// Command coincap-exporter serves CoinCap data as Prometheus metrics.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/karalef/coincap"
+	"github.com/karalef/coincap/exporter"
+)
+
+func main() {
+	addr := flag.String("listen", ":9523", "address to listen on")
+	path := flag.String("path", "/metrics", "metrics path")
+	var cfg exporter.Config
+	flag.DurationVar(&cfg.Interval, "interval", 0, "polling interval (default 1m)")
+	flag.UintVar(&cfg.AssetLimit, "assets", 100, "maximum number of polled assets")
+	prices := flag.String("prices", "", "comma separated assets to stream prices for (polled assets if empty)")
+	flag.BoolVar(&cfg.NoStream, "no-stream", false, "disable the prices stream")
+	flag.Parse()
+
+	if *prices != "" {
+		cfg.Prices = strings.Split(*prices, ",")
+	}
+
+	e := exporter.New(&coincap.DefaultClient, cfg)
+	e.Start()
+
+	http.Handle(*path, e)
+	log.Printf("listening on %s", *addr)
+	err := http.ListenAndServe(*addr, nil)
+	e.Close()
+	log.Print(err)
+	os.Exit(1)
+}
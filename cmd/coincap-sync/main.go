@@ -0,0 +1,80 @@
+// Command coincap-sync periodically mirrors CoinCap data into a local store.
+package main
+
+import (
+	"errors"
+	"flag"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/karalef/coincap"
+	"github.com/karalef/coincap/store"
+	"github.com/karalef/coincap/syncd"
+)
+
+// parseCandles parses comma separated exchange:base:quote[:interval] series.
+func parseCandles(s string) ([]store.Series, error) {
+	var series []store.Series
+	for _, spec := range strings.Split(s, ",") {
+		parts := strings.Split(spec, ":")
+		if len(parts) < 3 || len(parts) > 4 {
+			return nil, errors.New("invalid candles series '" + spec + "'")
+		}
+		iv := coincap.Hour
+		if len(parts) == 4 {
+			var err error
+			if iv, err = coincap.ParseInterval(parts[3]); err != nil {
+				return nil, err
+			}
+		}
+		series = append(series, store.CandleSeries(parts[0], parts[1], parts[2], iv))
+	}
+	return series, nil
+}
+
+func main() {
+	var cfg syncd.Config
+	flag.StringVar(&cfg.Dir, "dir", "coincap-data", "store directory")
+	flag.DurationVar(&cfg.Assets, "assets", 0, "assets polling interval (disabled if zero)")
+	flag.UintVar(&cfg.AssetLimit, "asset-limit", 2000, "maximum number of polled assets")
+	flag.DurationVar(&cfg.Exchanges, "exchanges", 0, "exchanges polling interval (disabled if zero)")
+	flag.DurationVar(&cfg.Rates, "rates", 0, "rates polling interval (disabled if zero)")
+	flag.DurationVar(&cfg.Markets, "markets", 0, "markets polling interval (disabled if zero)")
+	candles := flag.String("candles", "", "comma separated exchange:base:quote[:interval] candle series")
+	flag.DurationVar(&cfg.CandlesEvery, "candles-every", 0, "candles sync interval (default 1h)")
+	flag.DurationVar(&cfg.CandlesLookback, "candles-lookback", 0, "synced candles history (default 720h)")
+	flag.IntVar(&cfg.RequestsPerMinute, "rpm", 100, "request budget per minute")
+	verbose := flag.Bool("v", false, "log every job run")
+	flag.Parse()
+
+	if *candles != "" {
+		var err error
+		if cfg.Candles, err = parseCandles(*candles); err != nil {
+			log.Fatal(err)
+		}
+	}
+	level := slog.LevelInfo
+	if *verbose {
+		level = slog.LevelDebug
+	}
+	cfg.Logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+	cfg.OnChange = func(c syncd.Change) {
+		cfg.Logger.Info("snapshot changed", "job", c.Job,
+			"added", c.Added, "removed", c.Removed, "changed", c.Changed)
+	}
+
+	d, err := syncd.New(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	d.Start()
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	<-sig
+	d.Close()
+}
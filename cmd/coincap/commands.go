@@ -0,0 +1,167 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/karalef/coincap"
+)
+
+func assets(c *coincap.Client, args []string) error {
+	fs, format := newFlagSet("assets")
+	search := fs.String("search", "", "search by asset ID or symbol")
+	ids := fs.String("ids", "", "comma separated asset IDs")
+	trim := trimFlags(fs)
+	parse(fs, args)
+
+	if *ids == "" {
+		list, _, err := c.AssetsSearch(*search, trim)
+		if err != nil {
+			return err
+		}
+		return output(*format, list)
+	}
+
+	if *search != "" || trim.Limit != 0 || trim.Offset != 0 {
+		return errors.New("-ids cannot be combined with -search, -limit or -offset")
+	}
+	res, err := c.AssetsByIDs(strings.Split(*ids, ","), nil)
+	if err != nil {
+		return err
+	}
+	if len(res.NotFound) > 0 {
+		fmt.Fprintln(os.Stderr, "coincap: assets not found:", strings.Join(res.NotFound, ","))
+	}
+	return output(*format, res.Assets)
+}
+
+func asset(c *coincap.Client, args []string) error {
+	fs, format := newFlagSet("asset")
+	id, err := oneArg(fs, args, "asset ID")
+	if err != nil {
+		return err
+	}
+	a, _, err := c.AssetByID(id)
+	if err != nil {
+		return err
+	}
+	if a == nil {
+		return errors.New("asset '" + id + "' not found")
+	}
+	return output(*format, []coincap.Asset{*a})
+}
+
+func history(c *coincap.Client, args []string) error {
+	fs, format := newFlagSet("history")
+	interval := newIntervalFlags(fs)
+	id, err := oneArg(fs, args, "asset ID")
+	if err != nil {
+		return err
+	}
+	params, err := interval.params()
+	if err != nil {
+		return err
+	}
+	list, _, err := c.AssetHistory(id, params)
+	if err != nil {
+		return err
+	}
+	return output(*format, list)
+}
+
+func markets(c *coincap.Client, args []string) error {
+	fs, format := newFlagSet("markets")
+	var p coincap.MarketsRequest
+	fs.StringVar(&p.ExchangeID, "exchange", "", "exchange ID")
+	fs.StringVar(&p.BaseSymbol, "base-symbol", "", "base symbol")
+	fs.StringVar(&p.BaseID, "base-id", "", "base asset ID")
+	fs.StringVar(&p.QuoteSymbol, "quote-symbol", "", "quote symbol")
+	fs.StringVar(&p.QuoteID, "quote-id", "", "quote asset ID")
+	fs.StringVar(&p.AssetSymbol, "asset-symbol", "", "asset symbol")
+	fs.StringVar(&p.AssetID, "asset-id", "", "asset ID")
+	trim := trimFlags(fs)
+	parse(fs, args)
+
+	list, _, err := c.Markets(p, trim)
+	if err != nil {
+		return err
+	}
+	return output(*format, list)
+}
+
+func assetMarkets(c *coincap.Client, args []string) error {
+	fs, format := newFlagSet("asset-markets")
+	trim := trimFlags(fs)
+	id, err := oneArg(fs, args, "asset ID")
+	if err != nil {
+		return err
+	}
+	list, _, err := c.AssetMarkets(id, trim)
+	if err != nil {
+		return err
+	}
+	return output(*format, list)
+}
+
+func candles(c *coincap.Client, args []string) error {
+	fs, format := newFlagSet("candles")
+	var p coincap.CandlesRequest
+	fs.StringVar(&p.ExchangeID, "exchange", "", "exchange ID (required)")
+	fs.StringVar(&p.BaseID, "base-id", "", "base asset ID (required)")
+	fs.StringVar(&p.QuoteID, "quote-id", "", "quote asset ID (required)")
+	interval := newIntervalFlags(fs)
+	trim := trimFlags(fs)
+	parse(fs, args)
+
+	params, err := interval.params()
+	if err != nil {
+		return err
+	}
+	list, _, err := c.Candles(p, params, trim)
+	if err != nil {
+		return err
+	}
+	return output(*format, list)
+}
+
+func rates(c *coincap.Client, args []string) error {
+	fs, format := newFlagSet("rates")
+	pos := parse(fs, args)
+	if len(pos) == 0 {
+		list, _, err := c.Rates()
+		if err != nil {
+			return err
+		}
+		return output(*format, list)
+	}
+	r, _, err := c.RateByID(pos[0])
+	if err != nil {
+		return err
+	}
+	if r == nil {
+		return errors.New("rate '" + pos[0] + "' not found")
+	}
+	return output(*format, []coincap.Rate{*r})
+}
+
+func exchanges(c *coincap.Client, args []string) error {
+	fs, format := newFlagSet("exchanges")
+	pos := parse(fs, args)
+	if len(pos) == 0 {
+		list, _, err := c.Exchanges()
+		if err != nil {
+			return err
+		}
+		return output(*format, list)
+	}
+	e, _, err := c.ExchangeByID(pos[0])
+	if err != nil {
+		return err
+	}
+	if e == nil {
+		return errors.New("exchange '" + pos[0] + "' not found")
+	}
+	return output(*format, []coincap.Exchange{*e})
+}
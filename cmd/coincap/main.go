@@ -0,0 +1,149 @@
+// Command coincap is a command-line client for the CoinCap API.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/karalef/coincap"
+)
+
+type command struct {
+	usage string
+	run   func(c *coincap.Client, args []string) error
+}
+
+var commands = map[string]command{
+	"assets":        {"[-search s] [-ids a,b] [-limit n] [-offset n]", assets},
+	"asset":         {"<id>", asset},
+	"history":       {"<id> [-interval h1] [-start t] [-end t]", history},
+	"markets":       {"[-exchange e] [-base-id b] [-quote-id q] ... [-limit n] [-offset n]", markets},
+	"asset-markets": {"<id> [-limit n] [-offset n]", assetMarkets},
+	"candles":       {"-exchange e -base-id b -quote-id q [-interval h1] [-start t] [-end t]", candles},
+	"rates":         {"[id]", rates},
+	"exchanges":     {"[id]", exchanges},
+	"watch":         {"[asset...] [-exchange e] [-refresh 500ms]", watch},
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: coincap <command> [flags]")
+	fmt.Fprintln(os.Stderr, "\ncommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-14s %s\n", name, commands[name].usage)
+	}
+	fmt.Fprintln(os.Stderr, "\nall commands except watch accept -o table|json|ndjson|csv")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+	if err := cmd.run(&coincap.DefaultClient, os.Args[2:]); err != nil {
+		fmt.Fprintln(os.Stderr, "coincap:", err)
+		os.Exit(1)
+	}
+}
+
+// newFlagSet creates a command flag set with the -o flag.
+// The format is validated while parsing, before any request is made.
+func newFlagSet(name string) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	format := "table"
+	fs.Func("o", "output format: table, json, ndjson or csv (default table)", func(s string) error {
+		switch s {
+		case "table", "json", "ndjson", "csv":
+			format = s
+			return nil
+		}
+		return errors.New("unknown output format '" + s + "'")
+	})
+	return fs, &format
+}
+
+func trimFlags(fs *flag.FlagSet) *coincap.TrimParams {
+	var t coincap.TrimParams
+	fs.UintVar(&t.Limit, "limit", 0, "maximum number of results")
+	fs.UintVar(&t.Offset, "offset", 0, "skip the first N results")
+	return &t
+}
+
+type intervalFlags struct {
+	interval   coincap.Interval
+	start, end string
+}
+
+func newIntervalFlags(fs *flag.FlagSet) *intervalFlags {
+	f := intervalFlags{interval: coincap.Hour}
+	var codes []string
+	for _, i := range coincap.CandleIntervals() {
+		codes = append(codes, i.String())
+	}
+	fs.Var(&f.interval, "interval", "interval: "+strings.Join(codes, ", "))
+	fs.StringVar(&f.start, "start", "", "start time (RFC3339 or duration before now, e.g. 24h)")
+	fs.StringVar(&f.end, "end", "", "end time (RFC3339 or duration before now)")
+	return &f
+}
+
+func parseTime(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+func (f *intervalFlags) params() (*coincap.IntervalParams, error) {
+	now := time.Now()
+	start, err := parseTime(f.start, now)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseTime(f.end, now)
+	if err != nil {
+		return nil, err
+	}
+	if !start.IsZero() && end.IsZero() {
+		end = now
+	}
+	return &coincap.IntervalParams{Interval: f.interval, Start: start, End: end, ClampEnd: true}, nil
+}
+
+// parse parses flags allowing positional arguments before them.
+func parse(fs *flag.FlagSet, args []string) []string {
+	var pos []string
+	for {
+		fs.Parse(args)
+		args = fs.Args()
+		if len(args) == 0 {
+			return pos
+		}
+		pos = append(pos, args[0])
+		args = args[1:]
+	}
+}
+
+func oneArg(fs *flag.FlagSet, args []string, name string) (string, error) {
+	pos := parse(fs, args)
+	if len(pos) != 1 {
+		return "", errors.New(fs.Name() + ": expected " + name)
+	}
+	return pos[0], nil
+}
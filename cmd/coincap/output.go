@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+func output[T any](format string, list []T) error {
+	switch format {
+	case "table":
+		return writeTable(list)
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(list)
+	case "ndjson":
+		enc := json.NewEncoder(os.Stdout)
+		for _, v := range list {
+			if err := enc.Encode(v); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "csv":
+		return writeCSV(list)
+	}
+	return errors.New("unknown output format '" + format + "'")
+}
+
+// columns returns the column names of the struct type from its json tags.
+func columns(t reflect.Type) []string {
+	cols := make([]string, t.NumField())
+	for i := range cols {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" {
+			name = f.Name
+		}
+		cols[i] = name
+	}
+	return cols
+}
+
+func row(v reflect.Value) []string {
+	r := make([]string, v.NumField())
+	for i := range r {
+		f := v.Field(i)
+		switch f.Kind() {
+		case reflect.Float32, reflect.Float64:
+			r[i] = strconv.FormatFloat(f.Float(), 'f', -1, 64)
+		default:
+			r[i] = fmt.Sprint(f.Interface())
+		}
+	}
+	return r
+}
+
+func writeTable[T any](list []T) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.ToUpper(strings.Join(columns(reflect.TypeOf(list).Elem()), "\t")))
+	for _, v := range list {
+		fmt.Fprintln(w, strings.Join(row(reflect.ValueOf(v)), "\t"))
+	}
+	return w.Flush()
+}
+
+func writeCSV[T any](list []T) error {
+	w := csv.NewWriter(os.Stdout)
+	w.Write(columns(reflect.TypeOf(list).Elem()))
+	for _, v := range list {
+		w.Write(row(reflect.ValueOf(v)))
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,13 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package main
+
+import "os"
+
+func termSize() (int, int) {
+	return 80, 24
+}
+
+func onResize() <-chan os.Signal {
+	return nil
+}
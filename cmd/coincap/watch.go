@@ -0,0 +1,211 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/karalef/coincap"
+)
+
+type tick struct {
+	first, last float64
+	dir         int
+	count       int
+}
+
+func (t *tick) update(p float64) {
+	if t.count == 0 {
+		t.first = p
+	}
+	switch {
+	case p > t.last && t.count > 0:
+		t.dir = 1
+	case p < t.last:
+		t.dir = -1
+	}
+	t.last = p
+	t.count++
+}
+
+type ticker struct {
+	rows  map[string]*tick
+	start time.Time
+	total int
+}
+
+func (t *ticker) update(key string, p float64) {
+	r, ok := t.rows[key]
+	if !ok {
+		r = &tick{}
+		t.rows[key] = r
+	}
+	r.update(p)
+	t.total++
+}
+
+func (t *ticker) render(title string, now time.Time) string {
+	width, height := termSize()
+
+	keys := make([]string, 0, len(t.rows))
+	for k := range t.rows {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString("\x1b[H\x1b[2J")
+	elapsed := now.Sub(t.start).Seconds()
+	lines := []string{
+		fmt.Sprintf("%s  %d messages  %.1f msg/s  (ctrl+c to exit)", title, t.total, float64(t.total)/elapsed),
+		fmt.Sprintf("%-28s %16s %2s %10s %8s", "KEY", "PRICE", "", "CHANGE", "MSG/S"),
+	}
+	for _, k := range keys {
+		r := t.rows[k]
+		arrow := " ="
+		if r.dir > 0 {
+			arrow = "\x1b[32m ▲\x1b[0m"
+		} else if r.dir < 0 {
+			arrow = "\x1b[31m ▼\x1b[0m"
+		}
+		var change float64
+		if r.first != 0 {
+			change = (r.last - r.first) / r.first * 100
+		}
+		rate := float64(r.count) / elapsed
+		lines = append(lines, fmt.Sprintf("%-28s %16s%s %9.3f%% %8.2f",
+			truncate(k, 28), strconv.FormatFloat(r.last, 'g', 10, 64), arrow, change, rate))
+	}
+	if len(lines) > height-1 {
+		lines = lines[:height-1]
+	}
+	for _, l := range lines {
+		b.WriteString(truncate(l, width))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// truncate limits the line to n visible runes.
+// Escape sequences are kept so colors are always reset.
+func truncate(s string, n int) string {
+	if visibleLen(s) <= n {
+		return s
+	}
+	var b strings.Builder
+	esc := false
+	for _, r := range s {
+		switch {
+		case r == '\x1b':
+			esc = true
+		case esc:
+			esc = r != 'm'
+		case n > 0:
+			n--
+		default:
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// visibleLen returns the length of the line without escape sequences.
+func visibleLen(s string) int {
+	n := 0
+	esc := false
+	for _, r := range s {
+		switch {
+		case r == '\x1b':
+			esc = true
+		case esc:
+			esc = r != 'm'
+		default:
+			n++
+		}
+	}
+	return n
+}
+
+func watch(c *coincap.Client, args []string) error {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	exchange := fs.String("exchange", "", "watch trades of the exchange instead of prices")
+	refresh := fs.Duration("refresh", 500*time.Millisecond, "screen refresh interval")
+	assets := parse(fs, args)
+
+	t := &ticker{rows: make(map[string]*tick), start: time.Now()}
+	var (
+		title  string
+		events <-chan func()
+		stop   func()
+		errf   func() error
+	)
+	if *exchange != "" {
+		s, err := c.Trades(*exchange)
+		if err != nil {
+			return err
+		}
+		title, stop, errf = "trades "+*exchange, s.Close, s.Err
+		events = adapt(s.DataChannel(), func(tr *coincap.Trade) func() {
+			return func() { t.update(tr.Base+"/"+tr.Quote, tr.Price) }
+		})
+	} else {
+		s, err := c.Prices(assets...)
+		if err != nil {
+			return err
+		}
+		title, stop, errf = "prices", s.Close, s.Err
+		events = adapt(s.DataChannel(), func(p map[string]float64) func() {
+			return func() {
+				for id, v := range p {
+					t.update(id, v)
+				}
+			}
+		})
+	}
+	defer stop()
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	defer signal.Stop(sig)
+	resize := onResize()
+
+	render := time.NewTicker(*refresh)
+	defer render.Stop()
+	for {
+		select {
+		case <-sig:
+			fmt.Print("\x1b[H\x1b[2J")
+			return nil
+		case ev, ok := <-events:
+			if !ok {
+				if err := errf(); err != nil {
+					return err
+				}
+				return errors.New("stream closed")
+			}
+			ev()
+		case <-resize:
+			fmt.Print(t.render(title, time.Now()))
+		case now := <-render.C:
+			fmt.Print(t.render(title, now))
+		}
+	}
+}
+
+func adapt[T any](ch <-chan T, fn func(T) func()) <-chan func() {
+	out := make(chan func())
+	go func() {
+		defer close(out)
+		for v := range ch {
+			out <- fn(v)
+		}
+	}()
+	return out
+}
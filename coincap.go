@@ -1,9 +1,11 @@
 package coincap
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -29,17 +31,52 @@ func NewClient(httpClient *http.Client, wsDialer *websocket.Dialer) Client {
 	if wsDialer == nil {
 		wsDialer = websocket.DefaultDialer
 	}
-	return Client{httpClient, wsDialer}
+	return Client{http: httpClient, ws: wsDialer}
 }
 
 // Client gives access to CoinCap API.
 type Client struct {
-	http *http.Client
-	ws   *websocket.Dialer
+	http  *http.Client
+	ws    *websocket.Dialer
+	hooks Hooks
+	log   *slog.Logger
 }
 
 func request[T any](c *Client, endpoint string, query url.Values) (T, Timestamp, error) {
-	resp, err := c.http.Do(&http.Request{
+	return requestContext[T](context.Background(), c, endpoint, query)
+}
+
+// requestContext is request that is canceled with the context.
+func requestContext[T any](ctx context.Context, c *Client, endpoint string, query url.Values) (T, Timestamp, error) {
+	if c.hooks == nil && c.log == nil {
+		return doRequest[T](ctx, c, endpoint, query, nil)
+	}
+
+	info := &RequestInfo{
+		Endpoint: endpoint,
+		Name:     endpointName(endpoint),
+		Query:    query,
+		Start:    time.Now(),
+	}
+	if c.hooks != nil {
+		c.hooks.BeforeRequest(info)
+	}
+	resp := &ResponseInfo{Request: info}
+	v, ts, err := doRequest[T](ctx, c, endpoint, query, resp)
+	resp.Duration = time.Since(info.Start)
+	resp.Err = err
+	if err == nil {
+		resp.Items = countItems(v)
+	}
+	if c.hooks != nil {
+		c.hooks.AfterRequest(resp)
+	}
+	c.logRequest(resp)
+	return v, ts, err
+}
+
+func doRequest[T any](ctx context.Context, c *Client, endpoint string, query url.Values, info *ResponseInfo) (T, Timestamp, error) {
+	req := &http.Request{
 		Method: http.MethodGet,
 		URL: &url.URL{
 			Scheme:   "https",
@@ -47,19 +84,29 @@ func request[T any](c *Client, endpoint string, query url.Values) (T, Timestamp,
 			Path:     "/v2/" + endpoint,
 			RawQuery: query.Encode(),
 		},
-	})
+	}
+	resp, err := c.http.Do(req.WithContext(ctx))
 	if err != nil {
 		var t T
 		return t, 0, err
 	}
 	defer resp.Body.Close()
 
+	body := &countingReader{r: resp.Body}
 	r, err := decodeJSON[struct {
 		Data      T         `json:"data"`
 		Timestamp Timestamp `json:"timestamp"`
-	}](resp.Body)
+	}](body)
+	if info != nil {
+		info.StatusCode = resp.StatusCode
+		info.Size = body.n
+	}
 
 	if err != nil {
+		var de *decodeError
+		if info != nil && errors.As(err, &de) {
+			info.body = de.body
+		}
 		var t T
 		return t, 0, errors.New("coincap (" + resp.Status + "): " + err.Error())
 	}
@@ -73,11 +120,25 @@ func decodeJSON[T any](r io.Reader) (*T, error) {
 	err := dec.Decode(&v)
 	if err != nil {
 		b, _ := io.ReadAll(io.MultiReader(dec.Buffered(), r))
-		return nil, errors.New(err.Error() + "\n" + string(b))
+		return nil, &decodeError{err: err, body: b}
 	}
 	return &v, nil
 }
 
+// decodeError contains the decoding error and the undecoded rest of the data.
+type decodeError struct {
+	err  error
+	body []byte
+}
+
+func (e *decodeError) Error() string {
+	return e.err.Error() + "\n" + string(e.body)
+}
+
+func (e *decodeError) Unwrap() error {
+	return e.err
+}
+
 // Timestamp represents CoinCap timestamp
 // (UNIX time in milliseconds).
 type Timestamp int64
@@ -174,36 +235,110 @@ func (p *TrimParams) setTo(v *url.Values) {
 }
 
 // IntervalParams contains interval and time span parameters.
+//
+// If only Start or only End is set, the other bound is set
+// DefaultLookback intervals away from it (End is limited to now).
 type IntervalParams struct {
 	Interval Interval  // point-in-time interval.
 	Start    time.Time // start time.
 	End      time.Time // end time.
+	ClampEnd bool      // use the current time instead of failing if End is after it.
+	Align    bool      // round Start and End down to the interval boundaries.
 }
 
-func (p *IntervalParams) setTo(v *url.Values, candles bool) error {
-	if p == nil {
-		p = &IntervalParams{}
+// DefaultLookback is the number of intervals used as the time span
+// when only one of the IntervalParams bounds is set.
+const DefaultLookback = 720
+
+// IntervalError describes invalid interval parameters.
+type IntervalError struct {
+	Interval   Interval
+	Start, End time.Time // resolved time span
+	Now        time.Time // validation time
+	Err        error     // ErrInvalidInterval, ErrInvalidTimeSpan or ErrIntervalBigger
+	Reason     string
+}
+
+func (e *IntervalError) Error() string {
+	msg := e.Err.Error() + ": " + e.Reason
+	if e.Err != ErrInvalidInterval {
+		dur := e.Interval.Duration()
+		msg += " (interval " + e.Interval.String() + " requires start < end <= now (" +
+			e.Now.Format(time.RFC3339) + ") and a span of at least " + dur.String() + ")"
+	}
+	return msg
+}
+
+func (e *IntervalError) Unwrap() error {
+	return e.Err
+}
+
+// resolve returns the time span to request.
+// Zero times mean no time span.
+func (p *IntervalParams) resolve(candles bool, now time.Time) (start, end time.Time, err error) {
+	fail := func(e error, reason string) (time.Time, time.Time, error) {
+		return time.Time{}, time.Time{}, &IntervalError{
+			Interval: p.Interval, Start: start, End: end, Now: now, Err: e, Reason: reason,
+		}
 	}
 
-	str, dur, ext := p.Interval.data()
-	if ext && !candles || str == "" {
-		return ErrInvalidInterval
+	if !p.Interval.ValidForCandles() {
+		return fail(ErrInvalidInterval, "unknown interval "+p.Interval.String())
+	}
+	if !candles && !p.Interval.ValidForHistory() {
+		return fail(ErrInvalidInterval, "interval "+p.Interval.String()+" is available for candles only")
 	}
 
-	v.Set("interval", str)
+	start, end = p.Start, p.End
+	if start.IsZero() && end.IsZero() {
+		return start, end, nil
+	}
 
-	if p.Start.IsZero() && p.End.IsZero() {
-		return nil
+	dur := p.Interval.Duration()
+	lookback := DefaultLookback * dur
+	switch {
+	case start.IsZero():
+		start = end.Add(-lookback)
+	case end.IsZero():
+		end = start.Add(lookback)
+		if end.After(now) {
+			end = now
+		}
+	}
+	if end.After(now) {
+		if !p.ClampEnd {
+			return fail(ErrInvalidTimeSpan, "end "+end.Format(time.RFC3339)+" is after now")
+		}
+		end = now
+	}
+	if p.Align {
+		start = TimestampFromTime(start).Truncate(p.Interval).Time()
+		end = TimestampFromTime(end).Truncate(p.Interval).Time()
 	}
 
-	if span := p.End.Sub(p.Start); span < 0 || p.Start.IsZero() || p.End.After(time.Now()) {
-		return ErrInvalidTimeSpan
+	if span := end.Sub(start); span < 0 {
+		return fail(ErrInvalidTimeSpan, "start "+start.Format(time.RFC3339)+" is after end "+end.Format(time.RFC3339))
 	} else if span < dur {
-		return ErrIntervalBigger
+		return fail(ErrIntervalBigger, "time span "+span.String()+" is shorter than the interval")
+	}
+	return start, end, nil
+}
+
+func (p *IntervalParams) setTo(v *url.Values, candles bool) error {
+	if p == nil {
+		p = &IntervalParams{}
+	}
+
+	start, end, err := p.resolve(candles, time.Now())
+	if err != nil {
+		return err
 	}
 
-	v.Set("start", Timestamp(p.Start.UnixMilli()).String())
-	v.Set("end", Timestamp(p.End.UnixMilli()).String())
+	v.Set("interval", p.Interval.String())
+	if !start.IsZero() {
+		v.Set("start", TimestampFromTime(start).String())
+		v.Set("end", TimestampFromTime(end).String())
+	}
 
 	return nil
 }
@@ -0,0 +1,304 @@
+package coincap
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Converter converts amounts between currencies using CoinCap rates.
+// Currencies can be referenced by rate ID (e.g. "bitcoin", "euro")
+// or by symbol (e.g. "BTC", "EUR").
+//
+// It is safe for concurrent use.
+type Converter struct {
+	mu      sync.RWMutex
+	rates   map[string]Rate   // by ID
+	symbols map[string]string // upper-cased symbol -> ID
+	updated Timestamp
+
+	stop chan struct{}
+	conf chan struct{}
+	err  error
+}
+
+// USD rate identifiers.
+const (
+	USDID     = "united-states-dollar"
+	USDSymbol = "USD"
+)
+
+// NewConverter creates a converter from the rates snapshot.
+func NewConverter(rates []Rate, ts Timestamp) *Converter {
+	c := &Converter{}
+	c.set(rates, ts)
+	return c
+}
+
+// Converter returns a converter based on the current rates snapshot.
+func (c *Client) Converter() (*Converter, error) {
+	rates, ts, err := c.Rates()
+	if err != nil {
+		return nil, err
+	}
+	return NewConverter(rates, ts), nil
+}
+
+// LiveConverter returns a converter that stays up to date.
+// Rates are refreshed every refresh interval (1 minute if not positive)
+// and the prices of the given assets (all assets if empty) are applied
+// from the Prices stream.
+// The converter must be closed with Close.
+func (c *Client) LiveConverter(refresh time.Duration, assets ...string) (*Converter, error) {
+	if refresh <= 0 {
+		refresh = time.Minute
+	}
+	conv, err := c.Converter()
+	if err != nil {
+		return nil, err
+	}
+	conv.stop = make(chan struct{})
+	conv.conf = make(chan struct{})
+	go conv.live(c, refresh, assets)
+	return conv, nil
+}
+
+func (c *Converter) live(client *Client, refresh time.Duration, assets []string) {
+	defer close(c.conf)
+
+	ticker := time.NewTicker(refresh)
+	defer ticker.Stop()
+
+	var stream *Stream[map[string]float64]
+	var prices <-chan map[string]float64
+	defer func() {
+		if stream != nil {
+			stream.Close()
+		}
+	}()
+
+	connect := func() {
+		s, err := client.Prices(assets...)
+		if err != nil {
+			c.setErr(err)
+			return
+		}
+		stream, prices = s, s.DataChannel()
+	}
+	connect()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			rates, ts, err := client.Rates()
+			if err != nil {
+				c.setErr(err)
+			} else {
+				c.set(rates, ts)
+			}
+			if stream == nil {
+				logStreamReconnect(client.log, "prices", c.Err())
+				connect()
+			}
+		case p, ok := <-prices:
+			if !ok {
+				c.setErr(stream.Err())
+				stream, prices = nil, nil
+				continue
+			}
+			c.apply(p)
+		}
+	}
+}
+
+// Close stops updating the live converter.
+// The converter remains usable with the last known rates.
+func (c *Converter) Close() {
+	if c.stop == nil {
+		return
+	}
+	select {
+	case <-c.stop:
+	default:
+		close(c.stop)
+	}
+	<-c.conf
+}
+
+// Err returns the last error that occurred while updating the live converter.
+func (c *Converter) Err() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.err
+}
+
+func (c *Converter) setErr(err error) {
+	c.mu.Lock()
+	c.err = err
+	c.mu.Unlock()
+}
+
+func (c *Converter) set(rates []Rate, ts Timestamp) {
+	m := make(map[string]Rate, len(rates)+1)
+	s := make(map[string]string, len(rates)+1)
+	for _, r := range rates {
+		m[r.ID] = r
+		sym := strings.ToUpper(r.Symbol)
+		if _, ok := s[sym]; !ok || r.Type == "fiat" {
+			s[sym] = r.ID
+		}
+	}
+	if _, ok := m[USDID]; !ok {
+		m[USDID] = Rate{ID: USDID, Symbol: USDSymbol, CurrencySymbol: "$", RateUSD: 1, Type: "fiat"}
+	}
+	if _, ok := s[USDSymbol]; !ok {
+		s[USDSymbol] = USDID
+	}
+
+	c.mu.Lock()
+	c.rates, c.symbols, c.updated = m, s, ts
+	c.mu.Unlock()
+}
+
+// apply updates USD rates from the Prices stream data.
+func (c *Converter) apply(prices map[string]float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id, p := range prices {
+		if r, ok := c.rates[id]; ok && p > 0 {
+			r.RateUSD = p
+			c.rates[id] = r
+		}
+	}
+	c.updated = TimestampFromTime(time.Now())
+}
+
+// Updated returns the time of the last rates update.
+func (c *Converter) Updated() Timestamp {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.updated
+}
+
+// Lookup returns the rate for the given ID or symbol.
+func (c *Converter) Lookup(currency string) (Rate, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.lookup(currency)
+}
+
+func (c *Converter) lookup(currency string) (Rate, bool) {
+	if r, ok := c.rates[currency]; ok {
+		return r, true
+	}
+	if id, ok := c.symbols[strings.ToUpper(currency)]; ok {
+		return c.rates[id], true
+	}
+	return Rate{}, false
+}
+
+// ErrUnknownCurrency is returned when the currency is not found in rates.
+var ErrUnknownCurrency = errors.New("unknown currency")
+
+// valueError is a sentinel error annotated with the offending value.
+type valueError struct {
+	err   error
+	value string
+}
+
+func (e *valueError) Error() string {
+	return e.err.Error() + " '" + e.value + "'"
+}
+
+func (e *valueError) Unwrap() error {
+	return e.err
+}
+
+func unknownCurrency(currency string) error {
+	return &valueError{ErrUnknownCurrency, currency}
+}
+
+// Rate returns the amount of 'to' currency for one unit of 'from' currency.
+func (c *Converter) Rate(from, to string) (float64, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.rate(from, to)
+}
+
+func (c *Converter) rate(from, to string) (float64, error) {
+	f, ok := c.lookup(from)
+	if !ok || f.RateUSD == 0 {
+		return 0, unknownCurrency(from)
+	}
+	t, ok := c.lookup(to)
+	if !ok || t.RateUSD == 0 {
+		return 0, unknownCurrency(to)
+	}
+	return f.RateUSD / t.RateUSD, nil
+}
+
+// Convert converts amount of 'from' currency into 'to' currency.
+func (c *Converter) Convert(amount float64, from, to string) (float64, error) {
+	r, err := c.Rate(from, to)
+	return amount * r, err
+}
+
+// FromUSD converts USD amount into the target currency.
+func (c *Converter) FromUSD(amount float64, to string) (float64, error) {
+	return c.Convert(amount, USDID, to)
+}
+
+// Asset returns a copy of the asset with all USD values
+// (price, market cap, volume and VWAP) denominated in the target currency.
+func (c *Converter) Asset(a Asset, to string) (Asset, error) {
+	r, err := c.Rate(USDID, to)
+	if err != nil {
+		return a, err
+	}
+	a.PriceUsd *= r
+	a.MarketCapUsd *= r
+	a.VolumeUsd24Hr *= r
+	a.Vwap24Hr *= r
+	return a, nil
+}
+
+// Market returns a copy of the market with the quote price
+// and all USD values denominated in the target currency.
+// QuoteID and QuoteSymbol are not changed.
+func (c *Converter) Market(m Market, to string) (Market, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	usd, err := c.rate(USDID, to)
+	if err != nil {
+		return m, err
+	}
+	q, err := c.rate(m.QuoteID, to)
+	if err != nil {
+		return m, err
+	}
+	m.PriceQuote *= q
+	m.PriceUsd *= usd
+	m.VolumeUsd24Hr *= usd
+	return m, nil
+}
+
+// Candles returns a copy of the candles with prices converted from the
+// quote currency into the target currency.
+func (c *Converter) Candles(candles []Candle, quote, to string) ([]Candle, error) {
+	r, err := c.Rate(quote, to)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Candle, len(candles))
+	for i, cd := range candles {
+		cd.Open *= r
+		cd.High *= r
+		cd.Low *= r
+		cd.Close *= r
+		out[i] = cd
+	}
+	return out, nil
+}
@@ -0,0 +1,94 @@
+package coincap
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+var testRates = []Rate{
+	{ID: "bitcoin", Symbol: "BTC", RateUSD: 20000, Type: "crypto"},
+	{ID: "ethereum", Symbol: "ETH", RateUSD: 1500, Type: "crypto"},
+	{ID: "euro", Symbol: "EUR", CurrencySymbol: "€", RateUSD: 1.25, Type: "fiat"},
+	{ID: "tether", Symbol: "USDT", RateUSD: 1, Type: "crypto"},
+}
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestConverterConvert(t *testing.T) {
+	c := NewConverter(testRates, 0)
+
+	tests := []struct {
+		amount   float64
+		from, to string
+		want     float64
+	}{
+		{1, "ethereum", "euro", 1200},
+		{1, "ETH", "eur", 1200},
+		{2, "BTC", "ETH", 2 * 20000.0 / 1500},
+		{100, "USD", "EUR", 80},
+		{1, "bitcoin", "bitcoin", 1},
+	}
+	for _, tt := range tests {
+		got, err := c.Convert(tt.amount, tt.from, tt.to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !almostEqual(got, tt.want) {
+			t.Errorf("Convert(%v, %s, %s) = %v, want %v", tt.amount, tt.from, tt.to, got, tt.want)
+		}
+	}
+
+	_, err := c.Convert(1, "BTC", "XYZ")
+	if !errors.Is(err, ErrUnknownCurrency) || err.Error() != "unknown currency 'XYZ'" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestConverterDenominate(t *testing.T) {
+	c := NewConverter(testRates, 0)
+
+	a, err := c.Asset(Asset{PriceUsd: 100, MarketCapUsd: 1000}, "EUR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !almostEqual(a.PriceUsd, 80) || !almostEqual(a.MarketCapUsd, 800) {
+		t.Errorf("unexpected asset %+v", a)
+	}
+
+	m, err := c.Market(Market{QuoteID: "bitcoin", PriceQuote: 0.075, PriceUsd: 1500}, "EUR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !almostEqual(m.PriceQuote, 1200) || !almostEqual(m.PriceUsd, 1200) {
+		t.Errorf("unexpected market %+v", m)
+	}
+
+	cs, err := c.Candles([]Candle{{Open: 1, High: 2, Low: 0.5, Close: 1.5}}, "tether", "euro")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !almostEqual(cs[0].High, 1.6) {
+		t.Errorf("unexpected candle %+v", cs[0])
+	}
+
+	_, err = c.Candles(nil, "nope", "euro")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestConverterApply(t *testing.T) {
+	c := NewConverter(testRates, 0)
+	c.apply(map[string]float64{"bitcoin": 30000, "unknown": 1})
+
+	got, err := c.Convert(1, "BTC", "USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !almostEqual(got, 30000) {
+		t.Errorf("got %v, want 30000", got)
+	}
+}
@@ -0,0 +1,233 @@
+package coincap
+
+import (
+	"math"
+	"reflect"
+	"time"
+)
+
+// ChangeKind is a snapshot change kind.
+type ChangeKind uint8
+
+// Change kinds.
+const (
+	ChangeAdded ChangeKind = iota + 1
+	ChangeRemoved
+	ChangeRank
+	ChangeField
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case ChangeAdded:
+		return "added"
+	case ChangeRemoved:
+		return "removed"
+	case ChangeRank:
+		return "rank changed"
+	case ChangeField:
+		return "field changed"
+	}
+	return "unknown"
+}
+
+// Change is a difference between two snapshots.
+type Change[T any] struct {
+	Kind  ChangeKind
+	Key   string // item ID ("exchange/base/quote" for markets)
+	Item  T      // item from the new snapshot (from the old one if removed)
+	Field string // changed struct field name (ChangeField only)
+	Old   any    // old rank or field value (nil if added or removed)
+	New   any    // new rank or field value (nil if added or removed)
+}
+
+// DiffOptions selects the reported changes.
+// A nil *DiffOptions reports every change.
+type DiffOptions struct {
+	Fields    []string // compared struct fields including "Rank" (all if empty)
+	MinChange float64  // minimum relative change of float fields in percent
+}
+
+func (o *DiffOptions) compares(field string) bool {
+	if o == nil || len(o.Fields) == 0 {
+		return true
+	}
+	for _, f := range o.Fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// significant reports whether the field change passes the threshold.
+func (o *DiffOptions) significant(a, b any) bool {
+	if o == nil || o.MinChange <= 0 {
+		return true
+	}
+	x, ok := a.(float64)
+	if !ok {
+		return true
+	}
+	y := b.(float64)
+	if x == 0 {
+		return y != 0
+	}
+	return math.Abs((y-x)/x)*100 >= o.MinChange
+}
+
+// DiffAssets compares two assets snapshots.
+func DiffAssets(prev, cur []Asset, opts *DiffOptions) []Change[Asset] {
+	changes, _ := diffSnapshots(prev, cur, assetKey, opts)
+	return changes
+}
+
+// DiffExchanges compares two exchanges snapshots.
+// The Updated field is not compared.
+func DiffExchanges(prev, cur []Exchange, opts *DiffOptions) []Change[Exchange] {
+	changes, _ := diffSnapshots(prev, cur, exchangeKey, opts)
+	return changes
+}
+
+// DiffMarkets compares two markets snapshots.
+// The Updated field is not compared.
+func DiffMarkets(prev, cur []Market, opts *DiffOptions) []Change[Market] {
+	changes, _ := diffSnapshots(prev, cur, marketKey, opts)
+	return changes
+}
+
+// DiffRates compares two rates snapshots.
+func DiffRates(prev, cur []Rate, opts *DiffOptions) []Change[Rate] {
+	changes, _ := diffSnapshots(prev, cur, rateKey, opts)
+	return changes
+}
+
+func assetKey(a *Asset) string       { return a.ID }
+func exchangeKey(e *Exchange) string { return e.ID }
+func rateKey(r *Rate) string         { return r.ID }
+func marketKey(m *Market) string     { return m.ExchangeID + "/" + m.BaseID + "/" + m.QuoteID }
+
+// diffSnapshots reports added and changed items in the order of the current
+// snapshot followed by removed items in the order of the previous one.
+// Rank fields produce ChangeRank and Updated fields are skipped.
+//
+// It also returns the baseline for the next comparison: the current
+// snapshot with unreported field changes reverted to the previous values,
+// so changes below the threshold accumulate until they are reported.
+func diffSnapshots[T any](prev, cur []T, key func(*T) string, opts *DiffOptions) ([]Change[T], []T) {
+	old := make(map[string]*T, len(prev))
+	for i := range prev {
+		old[key(&prev[i])] = &prev[i]
+	}
+
+	var changes []Change[T]
+	base := make([]T, len(cur))
+	copy(base, cur)
+	seen := make(map[string]struct{}, len(cur))
+	for i := range cur {
+		k, item := key(&cur[i]), &cur[i]
+		seen[k] = struct{}{}
+		o, ok := old[k]
+		if !ok {
+			changes = append(changes, Change[T]{Kind: ChangeAdded, Key: k, Item: *item})
+			continue
+		}
+		ov, nv := reflect.ValueOf(o).Elem(), reflect.ValueOf(item).Elem()
+		bv := reflect.ValueOf(&base[i]).Elem()
+		for f := 0; f < nv.NumField(); f++ {
+			a, b := ov.Field(f).Interface(), nv.Field(f).Interface()
+			name := nv.Type().Field(f).Name
+			if a == b || name == "Updated" || !opts.compares(name) {
+				continue
+			}
+			if !opts.significant(a, b) {
+				bv.Field(f).Set(ov.Field(f))
+				continue
+			}
+			ch := Change[T]{Kind: ChangeField, Key: k, Item: *item, Field: name, Old: a, New: b}
+			if name == "Rank" {
+				ch.Kind, ch.Field = ChangeRank, ""
+			}
+			changes = append(changes, ch)
+		}
+	}
+	for i := range prev {
+		if _, ok := seen[key(&prev[i])]; !ok {
+			changes = append(changes, Change[T]{Kind: ChangeRemoved, Key: key(&prev[i]), Item: prev[i]})
+		}
+	}
+	return changes, base
+}
+
+// WatchAssets polls the assets every interval (1 minute if not positive)
+// and streams the changes selected by opts. Changes below opts.MinChange
+// accumulate until they pass it. Polling errors are logged and the next
+// poll is compared with the last successful snapshot.
+func (c *Client) WatchAssets(interval time.Duration, trim *TrimParams, opts *DiffOptions) (*Stream[Change[Asset]], error) {
+	return watchSnapshots(c, "assets", interval, func() ([]Asset, error) {
+		a, _, err := c.AssetsSearch("", trim)
+		return a, err
+	}, assetKey, opts)
+}
+
+// WatchExchanges polls the exchanges every interval and streams the changes.
+func (c *Client) WatchExchanges(interval time.Duration, opts *DiffOptions) (*Stream[Change[Exchange]], error) {
+	return watchSnapshots(c, "exchanges", interval, func() ([]Exchange, error) {
+		e, _, err := c.Exchanges()
+		return e, err
+	}, exchangeKey, opts)
+}
+
+// WatchMarkets polls the markets every interval and streams the changes.
+func (c *Client) WatchMarkets(interval time.Duration, params MarketsRequest, trim *TrimParams, opts *DiffOptions) (*Stream[Change[Market]], error) {
+	return watchSnapshots(c, "markets", interval, func() ([]Market, error) {
+		m, _, err := c.Markets(params, trim)
+		return m, err
+	}, marketKey, opts)
+}
+
+// WatchRates polls the rates every interval and streams the changes.
+func (c *Client) WatchRates(interval time.Duration, opts *DiffOptions) (*Stream[Change[Rate]], error) {
+	return watchSnapshots(c, "rates", interval, func() ([]Rate, error) {
+		r, _, err := c.Rates()
+		return r, err
+	}, rateKey, opts)
+}
+
+func watchSnapshots[T any](c *Client, name string, interval time.Duration,
+	fetch func() ([]T, error), key func(*T) string, opts *DiffOptions) (*Stream[Change[T]], error) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	last, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	s := newStream[Change[T]]()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.stop:
+				s.done(nil)
+				return
+			case <-ticker.C:
+			}
+			cur, err := fetch()
+			if err != nil {
+				logPollError(c.log, name, err)
+				continue
+			}
+			changes, base := diffSnapshots(last, cur, key, opts)
+			for _, ch := range changes {
+				if !s.send(ch) {
+					s.done(nil)
+					return
+				}
+			}
+			last = base
+		}
+	}()
+	return s, nil
+}
@@ -0,0 +1,112 @@
+package coincap
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiffExchanges(t *testing.T) {
+	old := []Exchange{
+		{ID: "binance", Rank: 1, Socket: true, Updated: 1},
+		{ID: "kraken", Rank: 2, Socket: true},
+		{ID: "gone", Rank: 3},
+	}
+	cur := []Exchange{
+		{ID: "kraken", Rank: 1, Socket: false},
+		{ID: "binance", Rank: 2, Socket: true, Updated: 2},
+		{ID: "bitfinex", Rank: 3},
+	}
+	changes := DiffExchanges(old, cur, nil)
+
+	type want struct {
+		kind     ChangeKind
+		key      string
+		field    string
+		old, new any
+	}
+	expected := []want{
+		{ChangeRank, "kraken", "", 2, 1},
+		{ChangeField, "kraken", "Socket", true, false},
+		{ChangeRank, "binance", "", 1, 2},
+		{ChangeAdded, "bitfinex", "", nil, nil},
+		{ChangeRemoved, "gone", "", nil, nil},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("expected %d changes, got %+v", len(expected), changes)
+	}
+	for i, w := range expected {
+		c := changes[i]
+		if c.Kind != w.kind || c.Key != w.key || c.Field != w.field || c.Old != w.old || c.New != w.new {
+			t.Errorf("change %d: expected %+v, got %+v", i, w, c)
+		}
+	}
+	if changes[4].Item.ID != "gone" {
+		t.Errorf("removed change must contain the old item")
+	}
+}
+
+func TestDiffOptions(t *testing.T) {
+	prev := []Asset{{ID: "bitcoin", Rank: 1, PriceUsd: 100, VolumeUsd24Hr: 10}}
+	cur := []Asset{{ID: "bitcoin", Rank: 2, PriceUsd: 100.5, VolumeUsd24Hr: 20}}
+
+	changes := DiffAssets(prev, cur, &DiffOptions{Fields: []string{"Rank", "PriceUsd"}})
+	if len(changes) != 2 || changes[0].Kind != ChangeRank || changes[1].Field != "PriceUsd" {
+		t.Errorf("unexpected changes %+v", changes)
+	}
+
+	opts := &DiffOptions{Fields: []string{"PriceUsd"}, MinChange: 1}
+	changes, base := diffSnapshots(prev, cur, assetKey, opts)
+	if len(changes) != 0 || base[0].PriceUsd != 100 {
+		t.Fatalf("small change must not be reported: %+v", changes)
+	}
+	// the change accumulates against the baseline
+	cur[0].PriceUsd = 101
+	changes, _ = diffSnapshots(base, cur, assetKey, opts)
+	if len(changes) != 1 || changes[0].Old != 100.0 || changes[0].New != 101.0 {
+		t.Errorf("unexpected changes %+v", changes)
+	}
+}
+
+func TestWatchRates(t *testing.T) {
+	bodies := make(chan string, 3)
+	bodies <- `{"data":[{"id":"euro","rateUsd":"1.1"},{"id":"yen","rateUsd":"0.01"}],"timestamp":1}`
+	bodies <- `{"data":[{"id":"euro","rateUsd":"1.2"},{"id":"pound","rateUsd":"1.3"}],"timestamp":2}`
+	c := NewClient(&http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		body := `{"data":[{"id":"euro","rateUsd":"1.2"},{"id":"pound","rateUsd":"1.3"}],"timestamp":3}`
+		select {
+		case body = <-bodies:
+		default:
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    r,
+		}, nil
+	})}, nil)
+
+	s, err := c.WatchRates(10*time.Millisecond, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	want := []ChangeKind{ChangeField, ChangeAdded, ChangeRemoved}
+	for i, kind := range want {
+		select {
+		case ch := <-s.DataChannel():
+			if ch.Kind != kind {
+				t.Errorf("change %d: expected %v, got %+v", i, kind, ch)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("no change received")
+		}
+	}
+	select {
+	case ch := <-s.DataChannel():
+		t.Errorf("unexpected change %+v", ch)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
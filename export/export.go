@@ -0,0 +1,233 @@
+// Package export writes CoinCap data to CSV, NDJSON and Parquet files.
+//
+// Columns are named after the JSON fields of the exported types and keep
+// the struct field order. Rows are written as they come so large data sets
+// do not have to be kept in memory.
+package export
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/karalef/coincap"
+)
+
+// Format is an export file format.
+type Format uint8
+
+// Export formats.
+const (
+	CSV    Format = iota
+	NDJSON        // NaN and infinite numbers are written as null
+	Parquet
+)
+
+// ParseFormat parses the format name ("csv", "ndjson" or "parquet").
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(s) {
+	case "csv":
+		return CSV, nil
+	case "ndjson", "jsonl":
+		return NDJSON, nil
+	case "parquet":
+		return Parquet, nil
+	}
+	return 0, errors.New("unknown export format '" + s + "'")
+}
+
+// TimeFormat specifies how timestamps are written.
+type TimeFormat uint8
+
+// Time formats.
+const (
+	Millis  TimeFormat = iota // UNIX time in milliseconds
+	RFC3339                   // RFC 3339 string in UTC with milliseconds
+)
+
+// Options contains export parameters.
+type Options struct {
+	TimeFormat   TimeFormat
+	RowGroupSize int // rows per Parquet row group (10000 if zero)
+}
+
+// Writer writes rows of T.
+// Close must be called to flush the data; it does not close the
+// underlying io.Writer.
+type Writer[T any] interface {
+	Write(row T) error
+	Close() error
+}
+
+// NewWriter creates a writer of the format.
+// T must be a struct type or a pointer to one, e.g. coincap.Candle
+// or *coincap.Trade.
+func NewWriter[T any](w io.Writer, format Format, opts *Options) (Writer[T], error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	s, err := schemaOf[T](opts.TimeFormat)
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case CSV:
+		return newCSV[T](w, s), nil
+	case NDJSON:
+		return newNDJSON[T](w, s), nil
+	case Parquet:
+		return newParquet[T](w, s, opts.RowGroupSize), nil
+	}
+	return nil, errors.New("unknown export format")
+}
+
+// WriteAll writes all rows and closes the writer.
+func WriteAll[T any](w Writer[T], rows []T) error {
+	for _, r := range rows {
+		if err := w.Write(r); err != nil {
+			w.Close()
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// WriteStream writes all values from the stream until it stops and closes
+// the writer. It returns the first write error or the stream error.
+func WriteStream[T any](w Writer[T], s *coincap.Stream[T]) error {
+	for v := range s.DataChannel() {
+		if err := w.Write(v); err != nil {
+			s.Close()
+			w.Close()
+			return err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return s.Err()
+}
+
+// Export writes all rows in the format.
+func Export[T any](w io.Writer, format Format, rows []T, opts *Options) error {
+	wr, err := NewWriter[T](w, format, opts)
+	if err != nil {
+		return err
+	}
+	return WriteAll(wr, rows)
+}
+
+type kind uint8
+
+const (
+	kindString kind = iota
+	kindInt
+	kindFloat
+	kindBool
+	kindTime
+)
+
+type column struct {
+	name  string
+	kind  kind
+	index int
+}
+
+type schema struct {
+	columns []column
+	ptr     bool
+	timeFmt TimeFormat
+}
+
+var (
+	timestampType = reflect.TypeOf(coincap.Timestamp(0))
+	stringerType  = reflect.TypeOf((*interface{ String() string })(nil)).Elem()
+)
+
+func schemaOf[T any](tf TimeFormat) (*schema, error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	s := &schema{timeFmt: tf}
+	if t.Kind() == reflect.Pointer {
+		t, s.ptr = t.Elem(), true
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, errors.New("export: " + t.String() + " is not a struct")
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		c := column{name: name, index: i}
+		switch {
+		case f.Type == timestampType:
+			c.kind = kindTime
+		case f.Type.Implements(stringerType):
+			c.kind = kindString
+		default:
+			switch f.Type.Kind() {
+			case reflect.String:
+				c.kind = kindString
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+				reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+				c.kind = kindInt
+			case reflect.Float32, reflect.Float64:
+				c.kind = kindFloat
+			case reflect.Bool:
+				c.kind = kindBool
+			default:
+				return nil, errors.New("export: unsupported field type " + f.Type.String())
+			}
+		}
+		s.columns = append(s.columns, c)
+	}
+	return s, nil
+}
+
+// value returns the struct value of the row.
+func (s *schema) value(row any) (reflect.Value, bool) {
+	v := reflect.ValueOf(row)
+	if s.ptr {
+		if v.IsNil() {
+			return v, false
+		}
+		v = v.Elem()
+	}
+	return v, true
+}
+
+func field(v reflect.Value, c *column) any {
+	f := v.Field(c.index)
+	switch c.kind {
+	case kindString:
+		if s, ok := f.Interface().(interface{ String() string }); ok {
+			return s.String()
+		}
+		return f.String()
+	case kindInt:
+		if f.CanInt() {
+			return f.Int()
+		}
+		return int64(f.Uint())
+	case kindFloat:
+		return f.Float()
+	case kindBool:
+		return f.Bool()
+	}
+	return coincap.Timestamp(f.Int())
+}
+
+func formatTime(ts coincap.Timestamp) string {
+	return ts.Time().UTC().Format("2006-01-02T15:04:05.000Z07:00")
+}
+
+// errNilRow is returned when writing a nil pointer row.
+var errNilRow = errors.New("export: nil row")
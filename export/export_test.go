@@ -0,0 +1,209 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"flag"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/karalef/coincap"
+)
+
+var testCandles = []coincap.Candle{
+	{Open: 1, High: 2, Low: 0.5, Close: 1.5, Volume: 10, Period: 1660000000000},
+	{Open: 1.5, High: 3, Low: 1, Close: 2.5, Volume: 20, Period: 1660003600000},
+}
+
+func TestCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Export(&buf, CSV, testCandles, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := "open,high,low,close,volume,period\n" +
+		"1,2,0.5,1.5,10,1660000000000\n" +
+		"1.5,3,1,2.5,20,1660003600000\n"
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestCSVEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Export[coincap.Candle](&buf, CSV, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if want := "open,high,low,close,volume,period\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	trades := []*coincap.Trade{{Exchange: "x", Base: "bitcoin", Quote: "tether", Direction: coincap.Sell,
+		Price: 2, Volume: 3, Timestamp: 1660000000000, PriceUSD: 2}}
+	if err := Export(&buf, NDJSON, trades, &Options{TimeFormat: RFC3339}); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"exchange":"x","base":"bitcoin","quote":"tether","direction":"sell","price":2,"volume":3,` +
+		`"timestamp":"2022-08-08T23:06:40.000Z","priceUsd":2}` + "\n"
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestNDJSONNaN(t *testing.T) {
+	var buf bytes.Buffer
+	rates := []coincap.Rate{{ID: "x", RateUSD: math.NaN()}, {ID: "y", RateUSD: math.Inf(1)}}
+	if err := Export(&buf, NDJSON, rates, nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		if !json.Valid(l) || !bytes.Contains(l, []byte(`"rateUsd":null`)) {
+			t.Errorf("unexpected line %s", l)
+		}
+	}
+}
+
+// thriftReader decodes thrift compact structs into maps by field ID.
+type thriftReader struct {
+	b []byte
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.b)
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *thriftReader) zigzag() int64 {
+	v := r.uvarint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftReader) value(typ byte) any {
+	switch typ {
+	case tI32, tI64:
+		return r.zigzag()
+	case tBinary:
+		n := r.uvarint()
+		s := string(r.b[:n])
+		r.b = r.b[n:]
+		return s
+	case tList:
+		h := r.b[0]
+		r.b = r.b[1:]
+		size, elem := int(h>>4), h&0x0F
+		if size == 15 {
+			size = int(r.uvarint())
+		}
+		l := make([]any, size)
+		for i := range l {
+			l[i] = r.value(elem)
+		}
+		return l
+	case tStruct:
+		m := make(map[int64]any)
+		var last int64
+		for {
+			h := r.b[0]
+			r.b = r.b[1:]
+			if h == 0 {
+				return m
+			}
+			id := last + int64(h>>4)
+			if h>>4 == 0 {
+				id = r.zigzag()
+			}
+			m[id] = r.value(h & 0x0F)
+			last = id
+		}
+	}
+	panic("unexpected thrift type")
+}
+
+func TestParquet(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter[coincap.Candle](&buf, Parquet, &Options{RowGroupSize: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteAll(w, testCandles); err != nil {
+		t.Fatal(err)
+	}
+
+	b := buf.Bytes()
+	if !bytes.HasPrefix(b, []byte("PAR1")) || !bytes.HasSuffix(b, []byte("PAR1")) {
+		t.Fatal("missing magic")
+	}
+	n := binary.LittleEndian.Uint32(b[len(b)-8:])
+	r := &thriftReader{b: b[len(b)-8-int(n) : len(b)-8]}
+	meta := r.value(tStruct).(map[int64]any)
+	if len(r.b) != 0 {
+		t.Fatalf("%d trailing footer bytes", len(r.b))
+	}
+
+	if meta[3] != int64(2) {
+		t.Errorf("num_rows = %v, want 2", meta[3])
+	}
+	schema := meta[2].([]any)
+	if len(schema) != 7 || schema[6].(map[int64]any)[4] != "period" || schema[6].(map[int64]any)[6] != int64(ctTimestampMillis) {
+		t.Errorf("unexpected schema %v", schema)
+	}
+	groups := meta[4].([]any)
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 row groups, got %d", len(groups))
+	}
+
+	// read the close price of the second row.
+	chunk := groups[1].(map[int64]any)[1].([]any)[3].(map[int64]any)[3].(map[int64]any)
+	off := chunk[9].(int64)
+	pr := &thriftReader{b: b[off:]}
+	page := pr.value(tStruct).(map[int64]any)
+	if page[2] != int64(8) {
+		t.Fatalf("unexpected page header %v", page)
+	}
+	if !bytes.Equal(pr.b[:8], []byte{0, 0, 0, 0, 0, 0, 0x04, 0x40}) {
+		t.Errorf("unexpected close value % x", pr.b[:8])
+	}
+}
+
+var update = flag.Bool("update", false, "update golden files")
+
+// testdata/exchanges.parquet pins the writer output. Files regenerated
+// with -update must be checked with an independent reader such as
+// pyarrow.parquet.read_table before they are committed.
+func TestParquetGolden(t *testing.T) {
+	exchanges := []coincap.Exchange{
+		{ID: "binance", Name: "Binance", Rank: 1, PercentTotalVolume: 40.5, VolumeUSD: 1e9,
+			TradingPairs: 900, Socket: true, URL: "https://www.binance.com/", Updated: 1660000000000},
+		{ID: "kraken", Name: "Kraken", Rank: 2, PercentTotalVolume: 10.25, VolumeUSD: 2.5e8,
+			TradingPairs: 400, Socket: false, URL: "https://kraken.com", Updated: 1660000060000},
+		{ID: "gdax", Name: "Coinbase Pro", Rank: 3, PercentTotalVolume: 9, VolumeUSD: 2e8,
+			TradingPairs: 300, Socket: true, URL: "https://pro.coinbase.com/", Updated: 1660000120000},
+	}
+	var buf bytes.Buffer
+	w, err := NewWriter[coincap.Exchange](&buf, Parquet, &Options{RowGroupSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteAll(w, exchanges); err != nil {
+		t.Fatal(err)
+	}
+
+	const golden = "testdata/exchanges.parquet"
+	if *update {
+		if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Error("output differs from " + golden)
+	}
+}
@@ -0,0 +1,340 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+
+	"github.com/karalef/coincap"
+)
+
+// This is a minimal Parquet writer: all columns are required, values use
+// PLAIN encoding and pages are not compressed. Every row group contains
+// a single data page per column.
+
+const parquetMagic = "PAR1"
+
+// parquet physical types.
+const (
+	ptBoolean   = 0
+	ptInt64     = 2
+	ptDouble    = 5
+	ptByteArray = 6
+)
+
+// parquet converted types.
+const (
+	ctUTF8            = 0
+	ctTimestampMillis = 9
+)
+
+// parquet encodings.
+const (
+	encPlain = 0
+	encRLE   = 3
+)
+
+type columnChunk struct {
+	offset int64
+	size   int64
+	values int64
+}
+
+type rowGroup struct {
+	columns []columnChunk
+	rows    int64
+	size    int64
+}
+
+type parquetWriter[T any] struct {
+	s      *schema
+	w      io.Writer
+	offset int64
+	err    error
+
+	groupSize int
+	rows      [][]any // buffered column values of the current row group
+	groups    []rowGroup
+	total     int64
+}
+
+func newParquet[T any](w io.Writer, s *schema, groupSize int) *parquetWriter[T] {
+	if groupSize <= 0 {
+		groupSize = 10000
+	}
+	return &parquetWriter[T]{s: s, w: w, groupSize: groupSize}
+}
+
+func (w *parquetWriter[T]) write(b []byte) {
+	if w.err != nil {
+		return
+	}
+	var n int
+	n, w.err = w.w.Write(b)
+	w.offset += int64(n)
+}
+
+func (w *parquetWriter[T]) Write(row T) error {
+	if w.err != nil {
+		return w.err
+	}
+	v, ok := w.s.value(row)
+	if !ok {
+		return errNilRow
+	}
+	if w.offset == 0 {
+		w.write([]byte(parquetMagic))
+	}
+	r := make([]any, len(w.s.columns))
+	for i := range w.s.columns {
+		r[i] = field(v, &w.s.columns[i])
+	}
+	w.rows = append(w.rows, r)
+	if len(w.rows) >= w.groupSize {
+		w.flushGroup()
+	}
+	return w.err
+}
+
+func (w *parquetWriter[T]) physical(c *column) (typ int32, conv int32) {
+	switch c.kind {
+	case kindString:
+		return ptByteArray, ctUTF8
+	case kindInt:
+		return ptInt64, -1
+	case kindFloat:
+		return ptDouble, -1
+	case kindBool:
+		return ptBoolean, -1
+	}
+	if w.s.timeFmt == RFC3339 {
+		return ptByteArray, ctUTF8
+	}
+	return ptInt64, ctTimestampMillis
+}
+
+func (w *parquetWriter[T]) encode(i int) []byte {
+	c := &w.s.columns[i]
+	var buf bytes.Buffer
+	var b8 [8]byte
+	if c.kind == kindBool {
+		packed := make([]byte, (len(w.rows)+7)/8)
+		for j, r := range w.rows {
+			if r[i].(bool) {
+				packed[j/8] |= 1 << (j % 8)
+			}
+		}
+		return packed
+	}
+	for _, r := range w.rows {
+		switch v := r[i].(type) {
+		case string:
+			binary.LittleEndian.PutUint32(b8[:4], uint32(len(v)))
+			buf.Write(b8[:4])
+			buf.WriteString(v)
+		case int64:
+			binary.LittleEndian.PutUint64(b8[:], uint64(v))
+			buf.Write(b8[:])
+		case float64:
+			binary.LittleEndian.PutUint64(b8[:], math.Float64bits(v))
+			buf.Write(b8[:])
+		case coincap.Timestamp:
+			if w.s.timeFmt == RFC3339 {
+				s := formatTime(v)
+				binary.LittleEndian.PutUint32(b8[:4], uint32(len(s)))
+				buf.Write(b8[:4])
+				buf.WriteString(s)
+			} else {
+				binary.LittleEndian.PutUint64(b8[:], uint64(v))
+				buf.Write(b8[:])
+			}
+		}
+	}
+	return buf.Bytes()
+}
+
+func (w *parquetWriter[T]) flushGroup() {
+	if len(w.rows) == 0 || w.err != nil {
+		return
+	}
+	g := rowGroup{rows: int64(len(w.rows))}
+	for i := range w.s.columns {
+		data := w.encode(i)
+
+		var h thriftWriter
+		h.fieldI32(1, 0) // DATA_PAGE
+		h.fieldI32(2, int32(len(data)))
+		h.fieldI32(3, int32(len(data)))
+		h.fieldStruct(5)
+		h.fieldI32(1, int32(len(w.rows)))
+		h.fieldI32(2, encPlain)
+		h.fieldI32(3, encRLE)
+		h.fieldI32(4, encRLE)
+		h.end()
+		h.end()
+
+		cc := columnChunk{offset: w.offset, values: int64(len(w.rows))}
+		w.write(h.buf.Bytes())
+		w.write(data)
+		cc.size = w.offset - cc.offset
+		g.columns = append(g.columns, cc)
+		g.size += cc.size
+	}
+	w.groups = append(w.groups, g)
+	w.total += g.rows
+	w.rows = w.rows[:0]
+}
+
+func (w *parquetWriter[T]) Close() error {
+	if w.offset == 0 && w.err == nil {
+		w.write([]byte(parquetMagic))
+	}
+	w.flushGroup()
+
+	var m thriftWriter
+	m.fieldI32(1, 1) // version
+	m.fieldList(2, tStruct, len(w.s.columns)+1)
+	m.fieldString(4, "schema")
+	m.fieldI32(5, int32(len(w.s.columns)))
+	m.end()
+	for i := range w.s.columns {
+		c := &w.s.columns[i]
+		typ, conv := w.physical(c)
+		m.fieldI32(1, typ)
+		m.fieldI32(3, 0) // REQUIRED
+		m.fieldString(4, c.name)
+		if conv >= 0 {
+			m.fieldI32(6, conv)
+		}
+		m.end()
+	}
+	m.fieldI64(3, w.total)
+	m.fieldList(4, tStruct, len(w.groups))
+	for _, g := range w.groups {
+		m.fieldList(1, tStruct, len(g.columns))
+		for i, cc := range g.columns {
+			typ, _ := w.physical(&w.s.columns[i])
+			m.fieldI64(2, cc.offset)
+			m.fieldStruct(3)
+			m.fieldI32(1, typ)
+			m.fieldList(2, tI32, 2)
+			m.listI32(encPlain)
+			m.listI32(encRLE)
+			m.fieldList(3, tBinary, 1)
+			m.listString(w.s.columns[i].name)
+			m.fieldI32(4, 0) // UNCOMPRESSED
+			m.fieldI64(5, cc.values)
+			m.fieldI64(6, cc.size)
+			m.fieldI64(7, cc.size)
+			m.fieldI64(9, cc.offset)
+			m.end()
+			m.end()
+		}
+		m.fieldI64(2, g.size)
+		m.fieldI64(3, g.rows)
+		m.end()
+	}
+	m.fieldString(6, "github.com/karalef/coincap/export")
+	m.end()
+
+	footer := m.buf.Bytes()
+	w.write(footer)
+	var n [4]byte
+	binary.LittleEndian.PutUint32(n[:], uint32(len(footer)))
+	w.write(n[:])
+	w.write([]byte(parquetMagic))
+	return w.err
+}
+
+// thrift compact protocol types.
+const (
+	tI32    = 5
+	tI64    = 6
+	tBinary = 8
+	tList   = 9
+	tStruct = 12
+)
+
+// thriftWriter encodes structs with the thrift compact protocol.
+type thriftWriter struct {
+	buf  bytes.Buffer
+	last []int16 // last field ID per nesting level
+}
+
+func (t *thriftWriter) uvarint(v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	t.buf.Write(b[:binary.PutUvarint(b[:], v)])
+}
+
+func (t *thriftWriter) zigzag(v int64) {
+	t.uvarint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (t *thriftWriter) field(id int16, typ byte) {
+	if len(t.last) == 0 {
+		t.last = append(t.last, 0)
+	}
+	last := &t.last[len(t.last)-1]
+	if d := id - *last; d > 0 && d <= 15 {
+		t.buf.WriteByte(byte(d)<<4 | typ)
+	} else {
+		t.buf.WriteByte(typ)
+		t.zigzag(int64(id))
+	}
+	*last = id
+}
+
+func (t *thriftWriter) fieldI32(id int16, v int32) {
+	t.field(id, tI32)
+	t.zigzag(int64(v))
+}
+
+func (t *thriftWriter) fieldI64(id int16, v int64) {
+	t.field(id, tI64)
+	t.zigzag(v)
+}
+
+func (t *thriftWriter) fieldString(id int16, s string) {
+	t.field(id, tBinary)
+	t.listString(s)
+}
+
+// fieldStruct begins a nested struct field; it must be finished with end.
+func (t *thriftWriter) fieldStruct(id int16) {
+	t.field(id, tStruct)
+	t.last = append(t.last, 0)
+}
+
+// fieldList begins a list field. Struct elements must be finished with end.
+func (t *thriftWriter) fieldList(id int16, elem byte, size int) {
+	t.field(id, tList)
+	if size < 15 {
+		t.buf.WriteByte(byte(size)<<4 | elem)
+	} else {
+		t.buf.WriteByte(0xF0 | elem)
+		t.uvarint(uint64(size))
+	}
+	if elem == tStruct {
+		for i := 0; i < size; i++ {
+			t.last = append(t.last, 0)
+		}
+	}
+}
+
+func (t *thriftWriter) listI32(v int32) {
+	t.zigzag(int64(v))
+}
+
+func (t *thriftWriter) listString(s string) {
+	t.uvarint(uint64(len(s)))
+	t.buf.WriteString(s)
+}
+
+// end finishes the current struct.
+func (t *thriftWriter) end() {
+	t.buf.WriteByte(0)
+	if len(t.last) > 0 {
+		t.last = t.last[:len(t.last)-1]
+	}
+}
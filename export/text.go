@@ -0,0 +1,132 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/karalef/coincap"
+)
+
+type csvWriter[T any] struct {
+	s      *schema
+	w      *csv.Writer
+	header bool
+	rec    []string
+}
+
+func newCSV[T any](w io.Writer, s *schema) *csvWriter[T] {
+	return &csvWriter[T]{s: s, w: csv.NewWriter(w), rec: make([]string, len(s.columns))}
+}
+
+func (w *csvWriter[T]) writeHeader() error {
+	if w.header {
+		return nil
+	}
+	w.header = true
+	for i, c := range w.s.columns {
+		w.rec[i] = c.name
+	}
+	return w.w.Write(w.rec)
+}
+
+func (w *csvWriter[T]) Write(row T) error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	v, ok := w.s.value(row)
+	if !ok {
+		return errNilRow
+	}
+	for i := range w.s.columns {
+		c := &w.s.columns[i]
+		switch f := field(v, c).(type) {
+		case string:
+			w.rec[i] = f
+		case int64:
+			w.rec[i] = strconv.FormatInt(f, 10)
+		case float64:
+			w.rec[i] = strconv.FormatFloat(f, 'f', -1, 64)
+		case bool:
+			w.rec[i] = strconv.FormatBool(f)
+		case coincap.Timestamp:
+			if w.s.timeFmt == RFC3339 {
+				w.rec[i] = formatTime(f)
+			} else {
+				w.rec[i] = f.String()
+			}
+		}
+	}
+	return w.w.Write(w.rec)
+}
+
+// Close writes the header if no rows were written and flushes the output.
+func (w *csvWriter[T]) Close() error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	w.w.Flush()
+	return w.w.Error()
+}
+
+type ndjsonWriter[T any] struct {
+	s   *schema
+	w   *bufio.Writer
+	buf []byte
+}
+
+func newNDJSON[T any](w io.Writer, s *schema) *ndjsonWriter[T] {
+	return &ndjsonWriter[T]{s: s, w: bufio.NewWriter(w)}
+}
+
+func (w *ndjsonWriter[T]) Write(row T) error {
+	v, ok := w.s.value(row)
+	if !ok {
+		return errNilRow
+	}
+	b := append(w.buf[:0], '{')
+	for i := range w.s.columns {
+		c := &w.s.columns[i]
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = strconv.AppendQuote(b, c.name)
+		b = append(b, ':')
+		switch f := field(v, c).(type) {
+		case string:
+			q, err := json.Marshal(f)
+			if err != nil {
+				return err
+			}
+			b = append(b, q...)
+		case int64:
+			b = strconv.AppendInt(b, f, 10)
+		case float64:
+			// JSON has no NaN and infinities
+			if math.IsNaN(f) || math.IsInf(f, 0) {
+				b = append(b, "null"...)
+			} else {
+				b = strconv.AppendFloat(b, f, 'f', -1, 64)
+			}
+		case bool:
+			b = strconv.AppendBool(b, f)
+		case coincap.Timestamp:
+			if w.s.timeFmt == RFC3339 {
+				b = strconv.AppendQuote(b, formatTime(f))
+			} else {
+				b = strconv.AppendInt(b, int64(f), 10)
+			}
+		}
+	}
+	b = append(b, '}', '\n')
+	w.buf = b
+	_, err := w.w.Write(b)
+	return err
+}
+
+func (w *ndjsonWriter[T]) Close() error {
+	return w.w.Flush()
+}
@@ -0,0 +1,367 @@
+// Package exporter exposes CoinCap data as Prometheus metrics.
+package exporter
+
+import (
+	"bufio"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/karalef/coincap"
+)
+
+// Config contains exporter parameters.
+type Config struct {
+	Interval   time.Duration // polling interval (1 minute if zero)
+	AssetLimit uint          // maximum number of polled assets (API default if zero)
+	Prices     []string      // assets to subscribe to prices for (assets polled at connection time if empty)
+	NoStream   bool          // disable the Prices stream
+}
+
+// Exporter polls CoinCap and serves the data in the Prometheus
+// text exposition format.
+type Exporter struct {
+	client *coincap.Client
+	cfg    Config
+
+	mu         sync.RWMutex
+	assets     map[string]coincap.Asset
+	exchanges  []coincap.Exchange
+	rates      []coincap.Rate
+	latency    map[string]*summary // by endpoint
+	errors     map[string]uint64   // by endpoint
+	reconnects uint64
+	messages   uint64
+
+	stop  chan struct{}
+	resub chan struct{} // the polled asset set changed
+	wg    sync.WaitGroup
+}
+
+type summary struct {
+	sum   float64
+	count uint64
+}
+
+// New creates a new exporter.
+func New(c *coincap.Client, cfg Config) *Exporter {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Minute
+	}
+	return &Exporter{
+		client:  c,
+		cfg:     cfg,
+		assets:  make(map[string]coincap.Asset),
+		latency: make(map[string]*summary),
+		errors:  make(map[string]uint64),
+		stop:    make(chan struct{}),
+		resub:   make(chan struct{}, 1),
+	}
+}
+
+// Start starts polling and streaming in the background.
+func (e *Exporter) Start() {
+	e.poll()
+	e.wg.Add(1)
+	go e.pollLoop()
+	if !e.cfg.NoStream {
+		e.wg.Add(1)
+		go e.streamLoop()
+	}
+}
+
+// Close stops the exporter.
+func (e *Exporter) Close() {
+	select {
+	case <-e.stop:
+	default:
+		close(e.stop)
+	}
+	e.wg.Wait()
+}
+
+func (e *Exporter) pollLoop() {
+	defer e.wg.Done()
+	t := time.NewTicker(e.cfg.Interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-e.stop:
+			return
+		case <-t.C:
+			e.poll()
+		}
+	}
+}
+
+// observe records the request duration and error.
+func (e *Exporter) observe(endpoint string, start time.Time, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	s, ok := e.latency[endpoint]
+	if !ok {
+		s = &summary{}
+		e.latency[endpoint] = s
+	}
+	s.sum += time.Since(start).Seconds()
+	s.count++
+	if err != nil {
+		e.errors[endpoint]++
+	}
+}
+
+func (e *Exporter) poll() {
+	start := time.Now()
+	assets, _, err := e.client.AssetsSearch("", &coincap.TrimParams{Limit: e.cfg.AssetLimit})
+	e.observe("assets", start, err)
+	if err == nil {
+		e.setAssets(assets)
+	}
+
+	start = time.Now()
+	exchanges, _, err := e.client.Exchanges()
+	e.observe("exchanges", start, err)
+	if err == nil {
+		e.mu.Lock()
+		e.exchanges = exchanges
+		e.mu.Unlock()
+	}
+
+	start = time.Now()
+	rates, _, err := e.client.Rates()
+	e.observe("rates", start, err)
+	if err == nil {
+		e.mu.Lock()
+		e.rates = rates
+		e.mu.Unlock()
+	}
+}
+
+// setAssets replaces the polled assets and requests the prices stream
+// to resubscribe if the set of asset IDs changed.
+func (e *Exporter) setAssets(assets []coincap.Asset) {
+	m := make(map[string]coincap.Asset, len(assets))
+	for _, a := range assets {
+		m[a.ID] = a
+	}
+	e.mu.Lock()
+	changed := len(m) != len(e.assets)
+	for id := range m {
+		if _, ok := e.assets[id]; !ok {
+			changed = true
+			break
+		}
+	}
+	e.assets = m
+	e.mu.Unlock()
+
+	if changed && len(e.cfg.Prices) == 0 {
+		select {
+		case e.resub <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (e *Exporter) streamLoop() {
+	defer e.wg.Done()
+	reconnect := false
+	for {
+		// the subscription below uses the current assets.
+		select {
+		case <-e.resub:
+		default:
+		}
+
+		wait := true
+		if ids := e.priceIDs(); len(ids) > 0 {
+			if reconnect {
+				e.mu.Lock()
+				e.reconnects++
+				e.mu.Unlock()
+			}
+			start := time.Now()
+			s, err := e.client.Prices(ids...)
+			e.observe("prices", start, err)
+			reconnect = true
+			if err == nil && e.consume(s) {
+				reconnect, wait = false, false
+			}
+		}
+
+		if !wait {
+			select {
+			case <-e.stop:
+				return
+			default:
+			}
+			continue
+		}
+		select {
+		case <-e.stop:
+			return
+		case <-e.resub:
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+// priceIDs returns the assets to subscribe to prices for.
+func (e *Exporter) priceIDs() []string {
+	if len(e.cfg.Prices) > 0 {
+		return e.cfg.Prices
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	ids := make([]string, 0, len(e.assets))
+	for id := range e.assets {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// consume applies the stream prices until the stream stops.
+// It reports whether the stream was closed to resubscribe.
+func (e *Exporter) consume(s *coincap.Stream[map[string]float64]) bool {
+	var resub bool
+	done, closed := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(closed)
+		select {
+		case <-e.stop:
+		case <-e.resub:
+			resub = true
+		case <-done:
+			return
+		}
+		s.Close()
+	}()
+
+	for prices := range s.DataChannel() {
+		e.mu.Lock()
+		e.messages++
+		for id, p := range prices {
+			a, ok := e.assets[id]
+			if !ok {
+				continue
+			}
+			a.PriceUsd = p
+			a.MarketCapUsd = a.Supply * p
+			e.assets[id] = a
+		}
+		e.mu.Unlock()
+	}
+	s.Close()
+	close(done)
+	<-closed
+	return resub
+}
+
+// ServeHTTP is http.Handler implementation.
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	bw := bufio.NewWriter(w)
+	e.write(bw)
+	bw.Flush()
+}
+
+type metric struct {
+	name, help, typ string
+	samples         []sample
+}
+
+type sample struct {
+	suffix string
+	labels [][2]string
+	value  float64
+}
+
+func (e *Exporter) metrics() []metric {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	price := metric{name: "coincap_asset_price_usd", help: "Asset price in USD.", typ: "gauge"}
+	mcap := metric{name: "coincap_asset_market_cap_usd", help: "Asset market capitalization in USD.", typ: "gauge"}
+	vol := metric{name: "coincap_asset_volume_usd_24h", help: "Asset trading volume in USD over the last 24 hours.", typ: "gauge"}
+	change := metric{name: "coincap_asset_change_percent_24h", help: "Asset price change in percent over the last 24 hours.", typ: "gauge"}
+	rank := metric{name: "coincap_asset_rank", help: "Asset rank by market capitalization.", typ: "gauge"}
+	for _, a := range e.assets {
+		l := [][2]string{{"id", a.ID}, {"symbol", a.Symbol}}
+		price.samples = append(price.samples, sample{"", l, a.PriceUsd})
+		mcap.samples = append(mcap.samples, sample{"", l, a.MarketCapUsd})
+		vol.samples = append(vol.samples, sample{"", l, a.VolumeUsd24Hr})
+		change.samples = append(change.samples, sample{"", l, a.ChangePercent24Hr})
+		rank.samples = append(rank.samples, sample{"", l, float64(a.Rank)})
+	}
+
+	exVol := metric{name: "coincap_exchange_volume_usd", help: "Exchange daily volume in USD.", typ: "gauge"}
+	exPairs := metric{name: "coincap_exchange_trading_pairs", help: "Number of exchange trading pairs.", typ: "gauge"}
+	for _, x := range e.exchanges {
+		l := [][2]string{{"id", x.ID}, {"name", x.Name}}
+		exVol.samples = append(exVol.samples, sample{"", l, x.VolumeUSD})
+		exPairs.samples = append(exPairs.samples, sample{"", l, float64(x.TradingPairs)})
+	}
+
+	rate := metric{name: "coincap_rate_usd", help: "Currency rate in USD.", typ: "gauge"}
+	for _, r := range e.rates {
+		l := [][2]string{{"id", r.ID}, {"symbol", r.Symbol}, {"type", r.Type}}
+		rate.samples = append(rate.samples, sample{"", l, r.RateUSD})
+	}
+
+	lat := metric{name: "coincap_request_duration_seconds", help: "CoinCap request duration.", typ: "summary"}
+	for ep, s := range e.latency {
+		l := [][2]string{{"endpoint", ep}}
+		lat.samples = append(lat.samples, sample{"_sum", l, s.sum}, sample{"_count", l, float64(s.count)})
+	}
+	errs := metric{name: "coincap_request_errors_total", help: "CoinCap request errors.", typ: "counter"}
+	for ep, n := range e.errors {
+		errs.samples = append(errs.samples, sample{"", [][2]string{{"endpoint", ep}}, float64(n)})
+	}
+
+	return []metric{price, mcap, vol, change, rank, exVol, exPairs, rate, lat, errs,
+		{name: "coincap_stream_reconnects_total", help: "Prices stream reconnects.", typ: "counter",
+			samples: []sample{{value: float64(e.reconnects)}}},
+		{name: "coincap_stream_messages_total", help: "Prices stream messages.", typ: "counter",
+			samples: []sample{{value: float64(e.messages)}}},
+	}
+}
+
+func (e *Exporter) write(w *bufio.Writer) {
+	for _, m := range e.metrics() {
+		if len(m.samples) == 0 {
+			continue
+		}
+		sort.Slice(m.samples, func(i, j int) bool {
+			return labelString(m.samples[i].labels)+m.samples[i].suffix <
+				labelString(m.samples[j].labels)+m.samples[j].suffix
+		})
+		w.WriteString("# HELP " + m.name + " " + m.help + "\n")
+		w.WriteString("# TYPE " + m.name + " " + m.typ + "\n")
+		for _, s := range m.samples {
+			w.WriteString(m.name + s.suffix + labelString(s.labels) + " " +
+				strconv.FormatFloat(s.value, 'g', -1, 64) + "\n")
+		}
+	}
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func labelString(labels [][2]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, l := range labels {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(l[0] + `="` + labelEscaper.Replace(l[1]) + `"`)
+	}
+	b.WriteByte('}')
+	return b.String()
+}
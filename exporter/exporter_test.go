@@ -0,0 +1,76 @@
+package exporter
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/karalef/coincap"
+)
+
+func TestServeHTTP(t *testing.T) {
+	e := New(&coincap.DefaultClient, Config{})
+	e.assets["bitcoin"] = coincap.Asset{ID: "bitcoin", Symbol: "BTC", PriceUsd: 20000, MarketCapUsd: 4e11}
+	e.rates = []coincap.Rate{{ID: "euro", Symbol: "EUR", RateUSD: 1.1, Type: "fiat"}}
+	e.errors["rates"] = 2
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+
+	for _, want := range []string{
+		"# TYPE coincap_asset_price_usd gauge\n",
+		`coincap_asset_price_usd{id="bitcoin",symbol="BTC"} 20000` + "\n",
+		`coincap_asset_market_cap_usd{id="bitcoin",symbol="BTC"} 4e+11` + "\n",
+		`coincap_rate_usd{id="euro",symbol="EUR",type="fiat"} 1.1` + "\n",
+		`coincap_request_errors_total{endpoint="rates"} 2` + "\n",
+		"coincap_stream_reconnects_total 0\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("missing %q in:\n%s", want, body)
+		}
+	}
+}
+
+func TestPriceIDs(t *testing.T) {
+	e := New(&coincap.DefaultClient, Config{})
+	e.assets = map[string]coincap.Asset{"ethereum": {}, "bitcoin": {}}
+	if ids := e.priceIDs(); len(ids) != 2 || ids[0] != "bitcoin" || ids[1] != "ethereum" {
+		t.Errorf("unexpected ids %v", ids)
+	}
+	e.cfg.Prices = []string{"tether"}
+	if ids := e.priceIDs(); len(ids) != 1 || ids[0] != "tether" {
+		t.Errorf("unexpected ids %v", ids)
+	}
+}
+
+func TestSetAssetsResubscribe(t *testing.T) {
+	e := New(&coincap.DefaultClient, Config{})
+	resub := func() bool {
+		select {
+		case <-e.resub:
+			return true
+		default:
+			return false
+		}
+	}
+
+	e.setAssets([]coincap.Asset{{ID: "bitcoin"}, {ID: "ethereum"}})
+	if !resub() {
+		t.Error("no resubscription for new assets")
+	}
+	e.setAssets([]coincap.Asset{{ID: "ethereum", PriceUsd: 1}, {ID: "bitcoin"}})
+	if resub() {
+		t.Error("resubscription for the same assets")
+	}
+	e.setAssets([]coincap.Asset{{ID: "bitcoin"}, {ID: "tether"}})
+	if !resub() {
+		t.Error("no resubscription for changed assets")
+	}
+
+	e.cfg.Prices = []string{"bitcoin"}
+	e.setAssets([]coincap.Asset{{ID: "bitcoin"}})
+	if resub() {
+		t.Error("resubscription with configured prices")
+	}
+}
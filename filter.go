@@ -0,0 +1,66 @@
+package coincap
+
+// TradeFilter selects trades delivered by the trades stream.
+// Empty fields match any trade.
+type TradeFilter struct {
+	Bases          []string // base asset IDs
+	Quotes         []string // quote asset IDs
+	Direction      Side     // trade side
+	MinVolume      float64  // minimum traded amount of base asset
+	MinNotional    float64  // minimum traded amount in quote asset (volume * price)
+	MinNotionalUSD float64  // minimum traded amount in USD (volume * USD price)
+}
+
+// idSet returns the set of the IDs or nil if there are none.
+func idSet(ids []string) map[string]struct{} {
+	if len(ids) == 0 {
+		return nil
+	}
+	m := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		m[id] = struct{}{}
+	}
+	return m
+}
+
+// Match reports whether the trade matches the filter.
+func (f *TradeFilter) Match(t *Trade) bool {
+	return f.matcher("")(t)
+}
+
+// matcher compiles the filter. The returned function also sets the
+// trade exchange if it is empty.
+func (f *TradeFilter) matcher(exchange string) func(*Trade) bool {
+	if f == nil {
+		return func(t *Trade) bool {
+			if t.Exchange == "" {
+				t.Exchange = exchange
+			}
+			return true
+		}
+	}
+	bases, quotes := idSet(f.Bases), idSet(f.Quotes)
+	dir := f.Direction
+	minVol, minNot, minUSD := f.MinVolume, f.MinNotional, f.MinNotionalUSD
+	return func(t *Trade) bool {
+		if t.Exchange == "" {
+			t.Exchange = exchange
+		}
+		if bases != nil {
+			if _, ok := bases[t.Base]; !ok {
+				return false
+			}
+		}
+		if quotes != nil {
+			if _, ok := quotes[t.Quote]; !ok {
+				return false
+			}
+		}
+		if dir != UnknownSide && t.Direction != dir {
+			return false
+		}
+		return t.Volume >= minVol &&
+			t.Volume*t.Price >= minNot &&
+			t.Volume*t.PriceUSD >= minUSD
+	}
+}
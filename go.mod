@@ -1,5 +1,5 @@
 module github.com/karalef/coincap
 
-go 1.18
+go 1.21
 
 require github.com/gorilla/websocket v1.5.0
@@ -0,0 +1,316 @@
+package coincap
+
+import (
+	"errors"
+	"math"
+	"strings"
+)
+
+// MarketGraph is a directed graph of assets connected by markets.
+// Every market produces two edges: base -> quote with the market price
+// and quote -> base with the inverse price.
+type MarketGraph struct {
+	edges   map[string][]MarketEdge // by source asset ID
+	symbols map[string]string       // upper-cased symbol -> asset ID
+}
+
+// MarketEdge is a single conversion step through a market.
+type MarketEdge struct {
+	From          string  // source asset ID
+	To            string  // destination asset ID
+	ExchangeID    string  // exchange of the market
+	Rate          float64 // amount of 'To' asset for one unit of 'From' asset
+	VolumeUsd24Hr float64 // market volume in the last 24 hours
+	Inverse       bool    // true if the step sells the market base for its quote
+}
+
+// NewMarketGraph builds a market graph.
+// Markets with non-positive quote price are skipped.
+func NewMarketGraph(markets []Market) *MarketGraph {
+	g := &MarketGraph{
+		edges:   make(map[string][]MarketEdge),
+		symbols: make(map[string]string),
+	}
+	for _, m := range markets {
+		if m.PriceQuote <= 0 || m.BaseID == "" || m.QuoteID == "" {
+			continue
+		}
+		g.edges[m.BaseID] = append(g.edges[m.BaseID], MarketEdge{
+			From:          m.BaseID,
+			To:            m.QuoteID,
+			ExchangeID:    m.ExchangeID,
+			Rate:          m.PriceQuote,
+			VolumeUsd24Hr: m.VolumeUsd24Hr,
+		})
+		g.edges[m.QuoteID] = append(g.edges[m.QuoteID], MarketEdge{
+			From:          m.QuoteID,
+			To:            m.BaseID,
+			ExchangeID:    m.ExchangeID,
+			Rate:          1 / m.PriceQuote,
+			VolumeUsd24Hr: m.VolumeUsd24Hr,
+			Inverse:       true,
+		})
+		g.addSymbol(m.BaseSymbol, m.BaseID)
+		g.addSymbol(m.QuoteSymbol, m.QuoteID)
+	}
+	return g
+}
+
+func (g *MarketGraph) addSymbol(symbol, id string) {
+	symbol = strings.ToUpper(symbol)
+	if _, ok := g.symbols[symbol]; !ok && symbol != "" {
+		g.symbols[symbol] = id
+	}
+}
+
+// MarketGraph fetches all markets matching the params and builds the graph.
+func (c *Client) MarketGraph(params MarketsRequest) (*MarketGraph, error) {
+	markets, err := c.AllMarkets(params)
+	if err != nil {
+		return nil, err
+	}
+	return NewMarketGraph(markets), nil
+}
+
+// AllMarkets requests all pages of market data matching the params.
+func (c *Client) AllMarkets(params MarketsRequest) ([]Market, error) {
+	return c.QueryMarkets(params, nil)
+}
+
+// Assets returns the IDs of all assets in the graph.
+func (g *MarketGraph) Assets() []string {
+	ids := make([]string, 0, len(g.edges))
+	for id := range g.edges {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Edges returns all conversion steps starting from the asset.
+func (g *MarketGraph) Edges(asset string) []MarketEdge {
+	return g.edges[g.resolve(asset)]
+}
+
+// resolve returns the asset ID by ID or symbol.
+func (g *MarketGraph) resolve(asset string) string {
+	if _, ok := g.edges[asset]; ok {
+		return asset
+	}
+	if id, ok := g.symbols[strings.ToUpper(asset)]; ok {
+		return id
+	}
+	return asset
+}
+
+// RouteCriterion specifies what makes a route the best.
+type RouteCriterion uint8
+
+// Route criteria.
+const (
+	ByPrice     RouteCriterion = iota // maximize the resulting amount
+	ByLiquidity                       // maximize the lowest market volume along the route
+	ByHops                            // minimize the number of conversions
+)
+
+// RouteOptions contains route finding parameters.
+type RouteOptions struct {
+	By        RouteCriterion
+	Exchanges []string // use only markets of these exchanges (all if empty)
+	MaxHops   int      // maximum number of conversions (4 if zero)
+}
+
+// Route is a sequence of conversions between two assets.
+type Route struct {
+	Steps     []MarketEdge
+	Rate      float64 // implied cross rate: amount of destination asset for one unit of source asset
+	Liquidity float64 // the lowest market volume along the route
+}
+
+// Hops returns the number of conversions.
+func (r *Route) Hops() int {
+	return len(r.Steps)
+}
+
+// route errors.
+var (
+	ErrNoRoute           = errors.New("no route found")
+	ErrInvalidCriterion  = errors.New("invalid route criterion")
+	ErrSameRouteEndpoint = errors.New("route source and destination are the same")
+)
+
+func (o *RouteOptions) filter() func(*MarketEdge) bool {
+	if len(o.Exchanges) == 0 {
+		return func(*MarketEdge) bool { return true }
+	}
+	set := make(map[string]struct{}, len(o.Exchanges))
+	for _, e := range o.Exchanges {
+		set[e] = struct{}{}
+	}
+	return func(e *MarketEdge) bool {
+		_, ok := set[e.ExchangeID]
+		return ok
+	}
+}
+
+// BestRoute finds the best route from one asset to another.
+// Assets can be referenced by ID or symbol.
+func (g *MarketGraph) BestRoute(from, to string, opts *RouteOptions) (*Route, error) {
+	if opts == nil {
+		opts = &RouteOptions{}
+	}
+	maxHops := opts.MaxHops
+	if maxHops <= 0 {
+		maxHops = 4
+	}
+	from, to = g.resolve(from), g.resolve(to)
+	if from == to {
+		return nil, ErrSameRouteEndpoint
+	}
+
+	var steps []MarketEdge
+	switch opts.By {
+	case ByPrice:
+		steps = g.bestByScore(from, to, maxHops, opts.filter(),
+			func(score float64, e *MarketEdge) float64 { return score * e.Rate }, 1)
+	case ByLiquidity:
+		steps = g.bestByScore(from, to, maxHops, opts.filter(),
+			func(score float64, e *MarketEdge) float64 { return math.Min(score, e.VolumeUsd24Hr) }, math.Inf(1))
+	case ByHops:
+		steps = g.fewestHops(from, to, maxHops, opts.filter())
+	default:
+		return nil, ErrInvalidCriterion
+	}
+	if steps == nil {
+		return nil, ErrNoRoute
+	}
+	return newRoute(steps), nil
+}
+
+// CrossRate returns the implied rate between two assets using the best route.
+func (g *MarketGraph) CrossRate(from, to string, opts *RouteOptions) (float64, error) {
+	r, err := g.BestRoute(from, to, opts)
+	if err != nil {
+		return 0, err
+	}
+	return r.Rate, nil
+}
+
+func newRoute(steps []MarketEdge) *Route {
+	r := &Route{Steps: steps, Rate: 1, Liquidity: math.Inf(1)}
+	for _, s := range steps {
+		r.Rate *= s.Rate
+		r.Liquidity = math.Min(r.Liquidity, s.VolumeUsd24Hr)
+	}
+	return r
+}
+
+type routeState struct {
+	score float64
+	path  []MarketEdge
+}
+
+// bestByScore performs a hop-bounded relaxation maximizing the score.
+// Only simple paths (without repeated assets) are considered.
+func (g *MarketGraph) bestByScore(from, to string, maxHops int, allow func(*MarketEdge) bool,
+	combine func(float64, *MarketEdge) float64, initial float64) []MarketEdge {
+
+	frontier := map[string]routeState{from: {score: initial}}
+	var best *routeState
+	for hop := 0; hop < maxHops && len(frontier) > 0; hop++ {
+		next := make(map[string]routeState)
+		for node, st := range frontier {
+			for i := range g.edges[node] {
+				e := &g.edges[node][i]
+				if !allow(e) || e.To == from || visits(st.path, e.To) {
+					continue
+				}
+				score := combine(st.score, e)
+				if cur, ok := next[e.To]; ok && cur.score >= score {
+					continue
+				}
+				path := make([]MarketEdge, len(st.path)+1)
+				copy(path, st.path)
+				path[len(st.path)] = *e
+				next[e.To] = routeState{score: score, path: path}
+			}
+		}
+		if st, ok := next[to]; ok && (best == nil || st.score > best.score) {
+			best = &st
+		}
+		delete(next, to)
+		frontier = next
+	}
+	if best == nil {
+		return nil
+	}
+	return best.path
+}
+
+func visits(path []MarketEdge, asset string) bool {
+	for i := range path {
+		if path[i].To == asset {
+			return true
+		}
+	}
+	return false
+}
+
+// fewestHops performs a breadth-first search preferring the most liquid
+// market among parallel ones.
+func (g *MarketGraph) fewestHops(from, to string, maxHops int, allow func(*MarketEdge) bool) []MarketEdge {
+	prev := map[string]MarketEdge{}
+	seen := map[string]bool{from: true}
+	level := []string{from}
+	for hop := 0; hop < maxHops && len(level) > 0; hop++ {
+		var next []string
+		found := map[string]MarketEdge{}
+		for _, node := range level {
+			for i := range g.edges[node] {
+				e := &g.edges[node][i]
+				if !allow(e) || seen[e.To] {
+					continue
+				}
+				if cur, ok := found[e.To]; !ok {
+					next = append(next, e.To)
+				} else if cur.VolumeUsd24Hr >= e.VolumeUsd24Hr {
+					continue
+				}
+				found[e.To] = *e
+			}
+		}
+		for id, e := range found {
+			seen[id] = true
+			prev[id] = e
+		}
+		if _, ok := found[to]; ok {
+			var path []MarketEdge
+			for node := to; node != from; {
+				e := prev[node]
+				path = append(path, e)
+				node = e.From
+			}
+			for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+				path[i], path[j] = path[j], path[i]
+			}
+			return path
+		}
+		level = next
+	}
+	return nil
+}
+
+// CrossRates returns implied rates from the asset to every reachable asset
+// using the best route by the given options.
+func (g *MarketGraph) CrossRates(from string, opts *RouteOptions) map[string]float64 {
+	from = g.resolve(from)
+	rates := make(map[string]float64)
+	for id := range g.edges {
+		if id == from {
+			continue
+		}
+		if r, err := g.BestRoute(from, id, opts); err == nil {
+			rates[id] = r.Rate
+		}
+	}
+	return rates
+}
@@ -0,0 +1,49 @@
+package coincap
+
+import "testing"
+
+var testMarkets = []Market{
+	{ExchangeID: "a", BaseID: "token", BaseSymbol: "TKN", QuoteID: "ethereum", QuoteSymbol: "ETH", PriceQuote: 0.001, VolumeUsd24Hr: 1000},
+	{ExchangeID: "a", BaseID: "ethereum", BaseSymbol: "ETH", QuoteID: "tether", QuoteSymbol: "USDT", PriceQuote: 1500, VolumeUsd24Hr: 1e9},
+	{ExchangeID: "b", BaseID: "token", BaseSymbol: "TKN", QuoteID: "bitcoin", QuoteSymbol: "BTC", PriceQuote: 0.0001, VolumeUsd24Hr: 5e5},
+	{ExchangeID: "b", BaseID: "bitcoin", BaseSymbol: "BTC", QuoteID: "tether", QuoteSymbol: "USDT", PriceQuote: 20000, VolumeUsd24Hr: 2e9},
+	{ExchangeID: "c", BaseID: "token", BaseSymbol: "TKN", QuoteID: "tether", QuoteSymbol: "USDT", PriceQuote: 1.4, VolumeUsd24Hr: 10},
+}
+
+func TestMarketGraphBestRoute(t *testing.T) {
+	g := NewMarketGraph(testMarkets)
+
+	tests := []struct {
+		opts  RouteOptions
+		hops  int
+		rate  float64
+		first string
+	}{
+		{RouteOptions{By: ByPrice}, 2, 2, "b"},
+		{RouteOptions{By: ByLiquidity}, 2, 2, "b"},
+		{RouteOptions{By: ByHops}, 1, 1.4, "c"},
+		{RouteOptions{By: ByPrice, Exchanges: []string{"a"}}, 2, 1.5, "a"},
+	}
+	for _, tt := range tests {
+		r, err := g.BestRoute("TKN", "tether", &tt.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Hops() != tt.hops || !almostEqual(r.Rate, tt.rate) || r.Steps[0].ExchangeID != tt.first {
+			t.Errorf("%+v: unexpected route %+v", tt.opts, r)
+		}
+	}
+
+	r, err := g.BestRoute("USDT", "token", &RouteOptions{By: ByHops})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Steps[0].Inverse || !almostEqual(r.Rate, 1/1.4) {
+		t.Errorf("unexpected inverse route %+v", r)
+	}
+
+	_, err = g.BestRoute("token", "tether", &RouteOptions{Exchanges: []string{"x"}})
+	if err != ErrNoRoute {
+		t.Fatalf("expected ErrNoRoute, got %v", err)
+	}
+}
@@ -0,0 +1,271 @@
+package coincap
+
+import (
+	"io"
+	"log/slog"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RequestInfo describes a REST request.
+type RequestInfo struct {
+	Endpoint string     // request path relative to the API root, e.g. "assets/bitcoin"
+	Name     string     // endpoint name with IDs replaced, e.g. "assets/{id}"
+	Query    url.Values // request query
+	Start    time.Time  // request start time
+}
+
+// ResponseInfo describes a completed REST request.
+type ResponseInfo struct {
+	Request    *RequestInfo
+	StatusCode int           // zero if the request failed before receiving a response
+	Duration   time.Duration // time from the request start to the decoded response
+	Size       int64         // response body size in bytes
+	Items      int           // number of decoded items
+	Err        error
+
+	body []byte // undecoded response body if decoding failed
+}
+
+// StreamInfo describes a websocket stream.
+type StreamInfo struct {
+	ID   uint64 // unique stream identifier within the process
+	Name string // stream name, "trades" or "prices"
+	URL  string
+}
+
+// Hooks receives client instrumentation events.
+// The methods are called synchronously and must not block.
+type Hooks interface {
+	BeforeRequest(req *RequestInfo)
+	AfterRequest(resp *ResponseInfo)
+	StreamConnect(s StreamInfo, err error)
+	StreamDisconnect(s StreamInfo, err error) // err ended the stream (nil if closed by the user)
+	StreamMessage(s StreamInfo, size int64)
+}
+
+// WithHooks returns a copy of the client that reports events to the hooks.
+// Multiple calls combine the hooks.
+func (c Client) WithHooks(h Hooks) Client {
+	if c.hooks != nil {
+		h = MultiHooks(c.hooks, h)
+	}
+	c.hooks = h
+	return c
+}
+
+// HookFuncs implements Hooks with optional functions.
+type HookFuncs struct {
+	OnBeforeRequest    func(req *RequestInfo)
+	OnAfterRequest     func(resp *ResponseInfo)
+	OnStreamConnect    func(s StreamInfo, err error)
+	OnStreamDisconnect func(s StreamInfo, err error)
+	OnStreamMessage    func(s StreamInfo, size int64)
+}
+
+// BeforeRequest is Hooks implementation.
+func (h HookFuncs) BeforeRequest(req *RequestInfo) {
+	if h.OnBeforeRequest != nil {
+		h.OnBeforeRequest(req)
+	}
+}
+
+// AfterRequest is Hooks implementation.
+func (h HookFuncs) AfterRequest(resp *ResponseInfo) {
+	if h.OnAfterRequest != nil {
+		h.OnAfterRequest(resp)
+	}
+}
+
+// StreamConnect is Hooks implementation.
+func (h HookFuncs) StreamConnect(s StreamInfo, err error) {
+	if h.OnStreamConnect != nil {
+		h.OnStreamConnect(s, err)
+	}
+}
+
+// StreamDisconnect is Hooks implementation.
+func (h HookFuncs) StreamDisconnect(s StreamInfo, err error) {
+	if h.OnStreamDisconnect != nil {
+		h.OnStreamDisconnect(s, err)
+	}
+}
+
+// StreamMessage is Hooks implementation.
+func (h HookFuncs) StreamMessage(s StreamInfo, size int64) {
+	if h.OnStreamMessage != nil {
+		h.OnStreamMessage(s, size)
+	}
+}
+
+type multiHooks []Hooks
+
+// MultiHooks combines several hooks into one.
+func MultiHooks(hooks ...Hooks) Hooks {
+	var m multiHooks
+	for _, h := range hooks {
+		if mh, ok := h.(multiHooks); ok {
+			m = append(m, mh...)
+		} else if h != nil {
+			m = append(m, h)
+		}
+	}
+	return m
+}
+
+func (m multiHooks) BeforeRequest(req *RequestInfo) {
+	for _, h := range m {
+		h.BeforeRequest(req)
+	}
+}
+
+func (m multiHooks) AfterRequest(resp *ResponseInfo) {
+	for _, h := range m {
+		h.AfterRequest(resp)
+	}
+}
+
+func (m multiHooks) StreamConnect(s StreamInfo, err error) {
+	for _, h := range m {
+		h.StreamConnect(s, err)
+	}
+}
+
+func (m multiHooks) StreamDisconnect(s StreamInfo, err error) {
+	for _, h := range m {
+		h.StreamDisconnect(s, err)
+	}
+}
+
+func (m multiHooks) StreamMessage(s StreamInfo, size int64) {
+	for _, h := range m {
+		h.StreamMessage(s, size)
+	}
+}
+
+// endpointName replaces the IDs in the endpoint path with "{id}".
+func endpointName(endpoint string) string {
+	parts := strings.Split(endpoint, "/")
+	if len(parts) > 1 {
+		parts[1] = "{id}"
+	}
+	return strings.Join(parts, "/")
+}
+
+// countItems returns the number of items in the decoded value.
+func countItems(v any) int {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Map:
+		return rv.Len()
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return 0
+		}
+	case reflect.Invalid:
+		return 0
+	}
+	return 1
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// SlogHooks returns hooks that log requests and stream lifecycle with the
+// logger using the same records as WithLogger. It is meant for combining
+// logging with other hooks via MultiHooks; WithLogger additionally logs
+// the reconnects of LiveAssets and LiveConverter.
+func SlogHooks(logger *slog.Logger) Hooks {
+	return HookFuncs{
+		OnAfterRequest: func(resp *ResponseInfo) {
+			logRequest(logger, resp)
+		},
+		OnStreamConnect: func(s StreamInfo, err error) {
+			logStreamConnect(logger, s, err)
+		},
+		OnStreamDisconnect: func(s StreamInfo, err error) {
+			logStreamDisconnect(logger, s, err)
+		},
+	}
+}
+
+// Tracer is an OpenTelemetry-style tracer.
+type Tracer interface {
+	Start(name string, attrs map[string]any) Span
+}
+
+// Span is an OpenTelemetry-style span.
+type Span interface {
+	SetAttributes(attrs map[string]any)
+	RecordError(err error)
+	End()
+}
+
+type tracerHooks struct {
+	HookFuncs
+	tracer Tracer
+	spans  sync.Map // *RequestInfo or stream ID -> Span
+}
+
+// TracerHooks returns hooks that create a span for every request
+// and for the lifetime of every stream.
+func TracerHooks(t Tracer) Hooks {
+	return &tracerHooks{tracer: t}
+}
+
+func (h *tracerHooks) BeforeRequest(req *RequestInfo) {
+	h.spans.Store(req, h.tracer.Start("coincap "+req.Name, map[string]any{
+		"coincap.endpoint": req.Endpoint,
+		"coincap.query":    req.Query.Encode(),
+	}))
+}
+
+func (h *tracerHooks) AfterRequest(resp *ResponseInfo) {
+	v, ok := h.spans.LoadAndDelete(resp.Request)
+	if !ok {
+		return
+	}
+	span := v.(Span)
+	span.SetAttributes(map[string]any{
+		"http.status_code": resp.StatusCode,
+		"coincap.size":     resp.Size,
+		"coincap.items":    resp.Items,
+	})
+	if resp.Err != nil {
+		span.RecordError(resp.Err)
+	}
+	span.End()
+}
+
+func (h *tracerHooks) StreamConnect(s StreamInfo, err error) {
+	span := h.tracer.Start("coincap stream "+s.Name, map[string]any{"coincap.url": s.URL})
+	if err != nil {
+		span.RecordError(err)
+		span.End()
+		return
+	}
+	h.spans.Store(s.ID, span)
+}
+
+func (h *tracerHooks) StreamDisconnect(s StreamInfo, err error) {
+	v, ok := h.spans.LoadAndDelete(s.ID)
+	if !ok {
+		return
+	}
+	span := v.(Span)
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
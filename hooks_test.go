@@ -0,0 +1,56 @@
+package coincap
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// testClient returns a client that responds to every request with the body.
+func testClient(status int, body string) Client {
+	return NewClient(&http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: status,
+			Status:     http.StatusText(status),
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    r,
+		}, nil
+	})}, nil)
+}
+
+func TestHooks(t *testing.T) {
+	const body = `{"data":[{"id":"bitcoin"},{"id":"ethereum"}],"timestamp":1}`
+	var before *RequestInfo
+	var after *ResponseInfo
+	c := testClient(http.StatusOK, body).WithHooks(HookFuncs{
+		OnBeforeRequest: func(req *RequestInfo) { before = req },
+		OnAfterRequest:  func(resp *ResponseInfo) { after = resp },
+	})
+
+	if _, _, err := c.AssetMarkets("bitcoin", nil); err != nil {
+		t.Fatal(err)
+	}
+	if before == nil || before.Name != "assets/{id}/markets" || before.Endpoint != "assets/bitcoin/markets" {
+		t.Fatalf("unexpected request info %+v", before)
+	}
+	if after == nil || after.Request != before || after.StatusCode != 200 ||
+		after.Items != 2 || after.Size != int64(len(body)) || after.Err != nil {
+		t.Fatalf("unexpected response info %+v", after)
+	}
+
+	c = testClient(http.StatusInternalServerError, "oops").WithHooks(HookFuncs{
+		OnAfterRequest: func(resp *ResponseInfo) { after = resp },
+	})
+	_, _, err := c.Rates()
+	if err == nil || !errors.Is(after.Err, err) || after.StatusCode != 500 {
+		t.Fatalf("unexpected response info %+v", after)
+	}
+}
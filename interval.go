@@ -0,0 +1,116 @@
+package coincap
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// allIntervals contains all intervals ordered by duration.
+var allIntervals = func() []Interval {
+	var all []Interval
+	for i := Interval(0); ; i++ {
+		if str, _, _ := i.data(); str == "" {
+			break
+		}
+		all = append(all, i)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Duration() < all[j].Duration()
+	})
+	return all
+}()
+
+// HistoryIntervals returns the intervals valid for asset history
+// ordered by duration.
+func HistoryIntervals() []Interval {
+	var list []Interval
+	for _, i := range allIntervals {
+		if i.ValidForHistory() {
+			list = append(list, i)
+		}
+	}
+	return list
+}
+
+// CandleIntervals returns the intervals valid for candles ordered by duration.
+func CandleIntervals() []Interval {
+	return append([]Interval(nil), allIntervals...)
+}
+
+// ValidForHistory reports whether the interval can be used for asset history.
+func (i Interval) ValidForHistory() bool {
+	str, _, ext := i.data()
+	return str != "" && !ext
+}
+
+// ValidForCandles reports whether the interval can be used for candles.
+func (i Interval) ValidForCandles() bool {
+	str, _, _ := i.data()
+	return str != ""
+}
+
+// String returns the API code of the interval, e.g. "m15".
+func (i Interval) String() string {
+	if str, _, _ := i.data(); str != "" {
+		return str
+	}
+	return "Interval(" + utoa(uint(i)) + ")"
+}
+
+// Duration returns the duration of the interval.
+// It returns zero for invalid intervals.
+func (i Interval) Duration() time.Duration {
+	_, dur, _ := i.data()
+	return dur
+}
+
+// ParseInterval parses the interval from its API code ("m15", "h4", "d1")
+// or a duration ("15m", "4h", "1d", "1w").
+func ParseInterval(s string) (Interval, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for _, i := range allIntervals {
+		if i.String() == s {
+			return i, nil
+		}
+	}
+	if n := len(s); n > 1 && (s[n-1] == 'd' || s[n-1] == 'w') {
+		s = s[n-1:] + s[:n-1] // "1d" -> "d1"
+		for _, i := range allIntervals {
+			if i.String() == s {
+				return i, nil
+			}
+		}
+	} else if d, err := time.ParseDuration(s); err == nil {
+		for _, i := range allIntervals {
+			if i.Duration() == d {
+				return i, nil
+			}
+		}
+	}
+	return 0, &valueError{ErrInvalidInterval, s}
+}
+
+// MarshalText is encoding.TextMarshaler implementation.
+func (i Interval) MarshalText() ([]byte, error) {
+	str, _, _ := i.data()
+	if str == "" {
+		return nil, ErrInvalidInterval
+	}
+	return []byte(str), nil
+}
+
+// UnmarshalText is encoding.TextUnmarshaler implementation.
+func (i *Interval) UnmarshalText(text []byte) error {
+	v, err := ParseInterval(string(text))
+	if err != nil {
+		return err
+	}
+	*i = v
+	return nil
+}
+
+// Set is flag.Value implementation.
+func (i *Interval) Set(s string) error {
+	return i.UnmarshalText([]byte(s))
+}
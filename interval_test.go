@@ -0,0 +1,108 @@
+package coincap
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseInterval(t *testing.T) {
+	cases := map[string]Interval{
+		"m15":  FifteenMinutes,
+		"15m":  FifteenMinutes,
+		"4h":   FourHours,
+		"H4":   FourHours,
+		"1d":   Day,
+		"d1":   Day,
+		"1w":   Week,
+		"2h0m": TwoHours,
+	}
+	for s, want := range cases {
+		if got, err := ParseInterval(s); err != nil || got != want {
+			t.Errorf("ParseInterval(%q) = %v, %v; want %v", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"", "3h", "d2", "x"} {
+		if _, err := ParseInterval(s); !errors.Is(err, ErrInvalidInterval) {
+			t.Errorf("ParseInterval(%q) must fail", s)
+		}
+	}
+}
+
+func TestIntervalText(t *testing.T) {
+	var v struct {
+		I Interval `json:"i"`
+	}
+	if err := json.Unmarshal([]byte(`{"i":"h8"}`), &v); err != nil || v.I != EightHours {
+		t.Fatal(v.I, err)
+	}
+	b, err := json.Marshal(v)
+	if err != nil || string(b) != `{"i":"h8"}` {
+		t.Errorf("unexpected json %s, %v", b, err)
+	}
+	if _, err := Interval(200).MarshalText(); err == nil {
+		t.Error("invalid interval must not be marshaled")
+	}
+
+	var i Interval
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(&i, "interval", "")
+	if err := fs.Parse([]string{"-interval", "30m"}); err != nil || i != ThirtyMinutes {
+		t.Errorf("flag parsed %v, %v", i, err)
+	}
+}
+
+func TestIntervalLists(t *testing.T) {
+	h, c := HistoryIntervals(), CandleIntervals()
+	if len(h) != 9 || len(c) != 12 {
+		t.Fatalf("unexpected lists %v %v", h, c)
+	}
+	if c[0] != Minute || c[len(c)-1] != Week || c[6].Duration() != 4*time.Hour {
+		t.Errorf("candle intervals are not ordered: %v", c)
+	}
+	for _, i := range h {
+		if i == FourHours || i == EightHours || i == Week {
+			t.Errorf("%v is not valid for history", i)
+		}
+	}
+}
+
+func TestIntervalParams(t *testing.T) {
+	now := time.Date(2022, 8, 8, 13, 47, 0, 0, time.UTC)
+
+	p := IntervalParams{Interval: Hour, End: now.Add(time.Minute)}
+	_, _, err := p.resolve(false, now)
+	if !errors.Is(err, ErrInvalidTimeSpan) || !strings.Contains(err.Error(), "at least 1h0m0s") {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	p.ClampEnd, p.Align = true, true
+	start, end, err := p.resolve(false, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !end.Equal(time.Date(2022, 8, 8, 13, 0, 0, 0, time.UTC)) || end.Sub(start) != DefaultLookback*time.Hour {
+		t.Errorf("unexpected span %v - %v", start, end)
+	}
+
+	p = IntervalParams{Interval: Day, Start: now.Add(-48 * time.Hour)}
+	if _, end, err = p.resolve(false, now); err != nil || !end.Equal(now) {
+		t.Errorf("end must be limited to now: %v, %v", end, err)
+	}
+
+	p = IntervalParams{Interval: Day, Start: now.Add(-time.Hour), End: now}
+	if _, _, err = p.resolve(false, now); !errors.Is(err, ErrIntervalBigger) {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	p = IntervalParams{Interval: Week}
+	if _, _, err = p.resolve(false, now); !errors.Is(err, ErrInvalidInterval) {
+		t.Errorf("unexpected error %v", err)
+	}
+	if _, _, err = p.resolve(true, now); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,264 @@
+package coincap
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// LiveAssetsOptions contains live assets parameters.
+type LiveAssetsOptions struct {
+	IDs    []string      // assets to track (top assets by API limit if empty)
+	Limit  uint          // number of top assets to track if IDs is empty (API default if zero)
+	Resync time.Duration // REST re-sync interval (5 minutes if zero)
+}
+
+// AssetUpdate is a live asset change notification.
+type AssetUpdate struct {
+	Asset   Asset // the updated asset
+	Old     Asset // the asset before the update (zero if added)
+	Resync  bool  // true if the update comes from the REST re-sync
+	Removed bool  // true if the asset is no longer tracked (Asset is zero)
+}
+
+// LiveAssets keeps the assets snapshot up to date by applying price
+// changes from the Prices stream and periodically re-syncing from REST.
+//
+// It is safe for concurrent use.
+type LiveAssets struct {
+	client *Client
+	opts   LiveAssetsOptions
+
+	mu      sync.RWMutex
+	assets  map[string]Asset
+	updated Timestamp
+	err     error
+	subs    map[chan<- AssetUpdate]struct{}
+
+	stop chan struct{}
+	conf chan struct{}
+}
+
+// LiveAssets loads the assets snapshot and starts applying updates.
+// It must be closed with Close.
+func (c *Client) LiveAssets(opts *LiveAssetsOptions) (*LiveAssets, error) {
+	l := &LiveAssets{
+		client: c,
+		assets: make(map[string]Asset),
+		subs:   make(map[chan<- AssetUpdate]struct{}),
+		stop:   make(chan struct{}),
+		conf:   make(chan struct{}),
+	}
+	if opts != nil {
+		l.opts = *opts
+	}
+	if l.opts.Resync <= 0 {
+		l.opts.Resync = 5 * time.Minute
+	}
+	if _, err := l.resync(); err != nil {
+		return nil, err
+	}
+	go l.run()
+	return l, nil
+}
+
+func (l *LiveAssets) fetch() ([]Asset, Timestamp, error) {
+	if len(l.opts.IDs) > 0 {
+		res, err := l.client.AssetsByIDs(l.opts.IDs, nil)
+		if err != nil {
+			return nil, 0, err
+		}
+		return res.Assets, res.Timestamp, nil
+	}
+	return l.client.AssetsSearch("", &TrimParams{Limit: l.opts.Limit})
+}
+
+// resync replaces the snapshot with the REST data and reports whether
+// the set of tracked assets has changed.
+func (l *LiveAssets) resync() (bool, error) {
+	assets, ts, err := l.fetch()
+	if err != nil {
+		return false, err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fresh := make(map[string]Asset, len(assets))
+	changed := false
+	for _, a := range assets {
+		fresh[a.ID] = a
+		old, ok := l.assets[a.ID]
+		if !ok {
+			changed = true
+		}
+		if old != a {
+			l.notify(AssetUpdate{Asset: a, Old: old, Resync: true})
+		}
+	}
+	for id, old := range l.assets {
+		if _, ok := fresh[id]; !ok {
+			changed = true
+			l.notify(AssetUpdate{Old: old, Resync: true, Removed: true})
+		}
+	}
+	l.assets, l.updated = fresh, ts
+	return changed, nil
+}
+
+func (l *LiveAssets) ids() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	ids := make([]string, 0, len(l.assets))
+	for id := range l.assets {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func (l *LiveAssets) run() {
+	defer close(l.conf)
+
+	ticker := time.NewTicker(l.opts.Resync)
+	defer ticker.Stop()
+
+	var stream *Stream[map[string]float64]
+	var prices <-chan map[string]float64
+	defer func() {
+		if stream != nil {
+			stream.Close()
+		}
+	}()
+
+	connect := func() {
+		s, err := l.client.Prices(l.ids()...)
+		if err != nil {
+			l.setErr(err)
+			return
+		}
+		stream, prices = s, s.DataChannel()
+	}
+	connect()
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			changed, err := l.resync()
+			if err != nil {
+				l.setErr(err)
+			}
+			reason := l.Err()
+			if changed && stream != nil {
+				// resubscribe to the prices of the new set of assets
+				stream.Close()
+				stream, prices, reason = nil, nil, nil
+			}
+			if stream == nil {
+				logStreamReconnect(l.client.log, "prices", reason)
+				connect()
+			}
+		case p, ok := <-prices:
+			if !ok {
+				l.setErr(stream.Err())
+				stream, prices = nil, nil
+				continue
+			}
+			l.apply(p)
+		}
+	}
+}
+
+// apply applies the price changes.
+func (l *LiveAssets) apply(prices map[string]float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for id, p := range prices {
+		old, ok := l.assets[id]
+		if !ok || old.PriceUsd == p {
+			continue
+		}
+		a := old
+		a.PriceUsd = p
+		a.MarketCapUsd = a.Supply * p
+		l.assets[id] = a
+		l.notify(AssetUpdate{Asset: a, Old: old})
+	}
+	l.updated = TimestampFromTime(time.Now())
+}
+
+// notify sends the update to all subscribers without blocking.
+func (l *LiveAssets) notify(u AssetUpdate) {
+	for ch := range l.subs {
+		select {
+		case ch <- u:
+		default:
+		}
+	}
+}
+
+// Subscribe registers the channel to receive asset updates.
+// Updates are dropped if the channel is not ready to receive.
+func (l *LiveAssets) Subscribe(ch chan<- AssetUpdate) {
+	l.mu.Lock()
+	l.subs[ch] = struct{}{}
+	l.mu.Unlock()
+}
+
+// Unsubscribe stops sending updates to the channel.
+func (l *LiveAssets) Unsubscribe(ch chan<- AssetUpdate) {
+	l.mu.Lock()
+	delete(l.subs, ch)
+	l.mu.Unlock()
+}
+
+// Get returns the asset by ID.
+func (l *LiveAssets) Get(id string) (Asset, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	a, ok := l.assets[id]
+	return a, ok
+}
+
+// All returns all assets ordered by rank.
+func (l *LiveAssets) All() []Asset {
+	l.mu.RLock()
+	all := make([]Asset, 0, len(l.assets))
+	for _, a := range l.assets {
+		all = append(all, a)
+	}
+	l.mu.RUnlock()
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Rank < all[j].Rank
+	})
+	return all
+}
+
+// Updated returns the time of the last update.
+func (l *LiveAssets) Updated() Timestamp {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.updated
+}
+
+// Err returns the last error that occurred while updating.
+func (l *LiveAssets) Err() error {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.err
+}
+
+func (l *LiveAssets) setErr(err error) {
+	l.mu.Lock()
+	l.err = err
+	l.mu.Unlock()
+}
+
+// Close stops updating.
+func (l *LiveAssets) Close() {
+	select {
+	case <-l.stop:
+	default:
+		close(l.stop)
+	}
+	<-l.conf
+}
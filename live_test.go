@@ -0,0 +1,95 @@
+package coincap
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// testLiveAssets returns live assets without the update loop.
+// Each resync responds with the next body.
+func testLiveAssets(bodies ...string) *LiveAssets {
+	c := NewClient(&http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		body := bodies[0]
+		bodies = bodies[1:]
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    r,
+		}, nil
+	})}, nil)
+	return &LiveAssets{
+		client: &c,
+		assets: make(map[string]Asset),
+		subs:   make(map[chan<- AssetUpdate]struct{}),
+	}
+}
+
+func TestLiveAssetsResync(t *testing.T) {
+	l := testLiveAssets(
+		`{"data":[{"id":"bitcoin","rank":"1","priceUsd":"100"},{"id":"ethereum","rank":"2","priceUsd":"10"}],"timestamp":1}`,
+		`{"data":[{"id":"bitcoin","rank":"1","priceUsd":"100"},{"id":"tether","rank":"2","priceUsd":"1"}],"timestamp":2}`,
+	)
+	updates := make(chan AssetUpdate, 10)
+	l.Subscribe(updates)
+
+	if changed, err := l.resync(); err != nil || !changed {
+		t.Fatal(changed, err)
+	}
+	if len(updates) != 2 {
+		t.Fatalf("expected 2 updates, got %d", len(updates))
+	}
+	for len(updates) > 0 {
+		<-updates
+	}
+
+	changed, err := l.resync()
+	if err != nil || !changed {
+		t.Fatal(changed, err)
+	}
+	var added, removed bool
+	for len(updates) > 0 {
+		u := <-updates
+		switch {
+		case u.Removed && u.Old.ID == "ethereum" && u.Asset.ID == "":
+			removed = true
+		case !u.Removed && u.Asset.ID == "tether" && u.Old.ID == "":
+			added = true
+		default:
+			t.Errorf("unexpected update %+v", u)
+		}
+	}
+	if !added || !removed {
+		t.Errorf("missing updates: added %v, removed %v", added, removed)
+	}
+	if _, ok := l.Get("ethereum"); ok || l.Updated() != 2 {
+		t.Error("snapshot is not replaced")
+	}
+}
+
+func TestLiveAssetsApply(t *testing.T) {
+	l := testLiveAssets(`{"data":[
+		{"id":"ethereum","rank":"2","supply":"100","priceUsd":"10","marketCapUsd":"1000"},
+		{"id":"bitcoin","rank":"1","supply":"10","priceUsd":"100","marketCapUsd":"1000"}
+	],"timestamp":1}`)
+	if _, err := l.resync(); err != nil {
+		t.Fatal(err)
+	}
+	updates := make(chan AssetUpdate, 10)
+	l.Subscribe(updates)
+
+	l.apply(map[string]float64{"bitcoin": 200, "ethereum": 10, "unknown": 1})
+	if len(updates) != 1 {
+		t.Fatalf("expected 1 update, got %d", len(updates))
+	}
+	u := <-updates
+	if u.Asset.PriceUsd != 200 || u.Asset.MarketCapUsd != 2000 || u.Old.PriceUsd != 100 || u.Resync {
+		t.Errorf("unexpected update %+v", u)
+	}
+
+	all := l.All()
+	if len(all) != 2 || all[0].ID != "bitcoin" || all[1].ID != "ethereum" {
+		t.Errorf("assets are not ordered by rank: %+v", all)
+	}
+}
@@ -0,0 +1,130 @@
+package coincap
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"unicode/utf8"
+)
+
+// maxLoggedBody is the maximum number of body bytes included in logs.
+const maxLoggedBody = 512
+
+// WithLogger returns a copy of the client that logs with the logger.
+//
+// Requests and stream lifecycle are logged at debug level, the
+// reconnects of LiveAssets and LiveConverter at info level, and failures
+// at warn level. Decoding failures include the truncated response body.
+//
+// WithLogger is the preferred way to log; SlogHooks produces the same
+// request and stream records through the Hooks interface.
+func (c Client) WithLogger(l *slog.Logger) Client {
+	c.log = l
+	return c
+}
+
+func truncateBody(b []byte) string {
+	if len(b) <= maxLoggedBody {
+		return string(b)
+	}
+	b = b[:maxLoggedBody]
+	for len(b) > 0 && !utf8.Valid(b) {
+		b = b[:len(b)-1]
+	}
+	return string(b) + "..."
+}
+
+func (c *Client) logRequest(resp *ResponseInfo) {
+	if c.log != nil {
+		logRequest(c.log, resp)
+	}
+}
+
+func logRequest(l *slog.Logger, resp *ResponseInfo) {
+	req := resp.Request
+	attrs := []slog.Attr{
+		slog.String("method", "GET"),
+		slog.String("endpoint", req.Endpoint),
+		slog.String("query", req.Query.Encode()),
+		slog.Int("status", resp.StatusCode),
+		slog.Duration("duration", resp.Duration),
+	}
+	if resp.Err != nil {
+		attrs = append(attrs, slog.String("error", firstLine(resp.Err.Error())))
+		if resp.body != nil {
+			attrs = append(attrs, slog.String("body", truncateBody(resp.body)))
+		}
+		l.LogAttrs(context.Background(), slog.LevelWarn, "coincap request failed", attrs...)
+		return
+	}
+	l.LogAttrs(context.Background(), slog.LevelDebug, "coincap request",
+		append(attrs, slog.Int64("size", resp.Size), slog.Int("items", resp.Items))...)
+}
+
+func logDecodeError(l *slog.Logger, msg string, err error, attrs ...slog.Attr) {
+	var de *decodeError
+	if !errors.As(err, &de) {
+		l.LogAttrs(context.Background(), slog.LevelWarn, msg, append(attrs, slog.Any("error", err))...)
+		return
+	}
+	l.LogAttrs(context.Background(), slog.LevelWarn, msg, append(attrs,
+		slog.String("error", de.err.Error()),
+		slog.String("body", truncateBody(de.body)))...)
+}
+
+func logStreamConnect(l *slog.Logger, s StreamInfo, err error) {
+	if l == nil {
+		return
+	}
+	if err != nil {
+		l.LogAttrs(context.Background(), slog.LevelWarn, "coincap stream connect failed",
+			slog.String("stream", s.Name), slog.String("url", s.URL), slog.Any("error", err))
+		return
+	}
+	l.LogAttrs(context.Background(), slog.LevelDebug, "coincap stream connected",
+		slog.String("stream", s.Name), slog.Uint64("id", s.ID), slog.String("url", s.URL))
+}
+
+func logStreamDisconnect(l *slog.Logger, s StreamInfo, err error) {
+	if l == nil {
+		return
+	}
+	if err == nil {
+		l.LogAttrs(context.Background(), slog.LevelDebug, "coincap stream closed",
+			slog.String("stream", s.Name), slog.Uint64("id", s.ID))
+		return
+	}
+	var de *decodeError
+	if errors.As(err, &de) {
+		logDecodeError(l, "coincap stream decoding failed", err,
+			slog.String("stream", s.Name), slog.Uint64("id", s.ID))
+		return
+	}
+	l.LogAttrs(context.Background(), slog.LevelWarn, "coincap stream disconnected",
+		slog.String("stream", s.Name), slog.Uint64("id", s.ID), slog.Any("error", err))
+}
+
+func logStreamReconnect(l *slog.Logger, name string, err error) {
+	if l == nil {
+		return
+	}
+	l.LogAttrs(context.Background(), slog.LevelInfo, "coincap stream reconnecting",
+		slog.String("stream", name), slog.Any("error", err))
+}
+
+func firstLine(s string) string {
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\n' {
+			return s[:i]
+		}
+	}
+	return s
+}
+
+func logPollError(l *slog.Logger, name string, err error) {
+	if l == nil {
+		return
+	}
+	l.LogAttrs(context.Background(), slog.LevelWarn, "coincap polling failed",
+		slog.String("snapshot", name), slog.String("error", firstLine(err.Error())))
+}
@@ -0,0 +1,45 @@
+package coincap
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestLogger(t *testing.T) {
+	var buf strings.Builder
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	c := testClient(http.StatusOK, `{"data":[],"timestamp":1}`).WithLogger(logger)
+	if _, _, err := c.Exchanges(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "level=DEBUG msg=\"coincap request\" method=GET endpoint=exchanges") {
+		t.Errorf("unexpected log output:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	c = testClient(http.StatusBadGateway, "<html>"+strings.Repeat("x", 1000)+"</html>").WithLogger(logger)
+	if _, _, err := c.Exchanges(); err == nil {
+		t.Fatal("expected error")
+	}
+	out := buf.String()
+	if strings.Count(out, "level=WARN") != 1 || !strings.Contains(out, "level=WARN msg=\"coincap request failed\"") ||
+		!strings.Contains(out, "status=502") || !strings.Contains(out, "body=<html>xxx") || strings.Contains(out, "</html>") {
+		t.Errorf("unexpected log output:\n%s", out)
+	}
+}
+
+func TestSlogHooks(t *testing.T) {
+	var buf strings.Builder
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	c := testClient(http.StatusOK, `{"data":[],"timestamp":1}`).WithHooks(SlogHooks(logger))
+	if _, _, err := c.Exchanges(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "level=DEBUG msg=\"coincap request\" method=GET endpoint=exchanges") {
+		t.Errorf("unexpected log output:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,376 @@
+// Package portfolio tracks holdings valuation and profit and loss
+// using CoinCap data.
+package portfolio
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/karalef/coincap"
+)
+
+// Method is a cost basis method.
+type Method uint8
+
+// Cost basis methods.
+const (
+	FIFO        Method = iota // first in, first out
+	LIFO                      // last in, first out
+	AverageCost               // weighted average cost
+)
+
+// Side is a transaction side.
+type Side uint8
+
+// Transaction sides.
+const (
+	Buy Side = iota
+	Sell
+)
+
+// Transaction is a buy or sell of an asset.
+// Prices and fees are in USD.
+type Transaction struct {
+	Asset    string    // asset ID
+	Side     Side      // buy or sell
+	Quantity float64   // amount of asset
+	Price    float64   // price per unit
+	Fee      float64   // total fee; added to cost on buy and subtracted from proceeds on sell
+	Time     time.Time // transaction time
+}
+
+// errors.
+var (
+	ErrInvalidTransaction = errors.New("invalid transaction")
+	ErrInsufficient       = errors.New("insufficient holdings")
+)
+
+// assetError is an error related to the asset.
+type assetError struct {
+	err   error
+	asset string
+}
+
+func (e *assetError) Error() string {
+	return e.err.Error() + " of '" + e.asset + "'"
+}
+
+func (e *assetError) Unwrap() error {
+	return e.err
+}
+
+// Portfolio contains transactions of assets.
+// It is not safe for concurrent use.
+type Portfolio struct {
+	method Method
+	txs    []Transaction
+}
+
+// New creates an empty portfolio using the cost basis method.
+func New(method Method) *Portfolio {
+	return &Portfolio{method: method}
+}
+
+// Hold declares an opening holding of the asset with the average unit cost.
+func (p *Portfolio) Hold(asset string, quantity, cost float64) error {
+	return p.Add(Transaction{Asset: asset, Side: Buy, Quantity: quantity, Price: cost})
+}
+
+// Add adds the transaction.
+// Selling more than held at the transaction time is an error.
+func (p *Portfolio) Add(tx Transaction) error {
+	if tx.Asset == "" || tx.Quantity <= 0 || tx.Price < 0 || tx.Side > Sell {
+		return ErrInvalidTransaction
+	}
+	txs := append(append([]Transaction(nil), p.txs...), tx)
+	sort.SliceStable(txs, func(i, j int) bool {
+		return txs[i].Time.Before(txs[j].Time)
+	})
+	if _, err := compute(txs, p.method, time.Time{}); err != nil {
+		return err
+	}
+	p.txs = txs
+	return nil
+}
+
+// Transactions returns all transactions ordered by time.
+func (p *Portfolio) Transactions() []Transaction {
+	return append([]Transaction(nil), p.txs...)
+}
+
+// Assets returns the IDs of all assets that have been held.
+func (p *Portfolio) Assets() []string {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, tx := range p.txs {
+		if !seen[tx.Asset] {
+			seen[tx.Asset] = true
+			ids = append(ids, tx.Asset)
+		}
+	}
+	return ids
+}
+
+// Holding is the position in a single asset.
+type Holding struct {
+	Asset     string
+	Quantity  float64 // amount held
+	CostBasis float64 // total cost of the amount held
+	Realized  float64 // realized profit and loss
+}
+
+// AverageCost returns the average cost per unit.
+func (h *Holding) AverageCost() float64 {
+	if h.Quantity == 0 {
+		return 0
+	}
+	return h.CostBasis / h.Quantity
+}
+
+// Holdings returns the current positions.
+func (p *Portfolio) Holdings() []Holding {
+	hs, _ := compute(p.txs, p.method, time.Time{})
+	return hs
+}
+
+// HoldingsAt returns the positions at the time.
+func (p *Portfolio) HoldingsAt(t time.Time) []Holding {
+	hs, _ := compute(p.txs, p.method, t)
+	return hs
+}
+
+type lot struct {
+	quantity float64
+	cost     float64 // per unit
+}
+
+// compute replays the transactions up to the time (all if zero).
+func compute(txs []Transaction, method Method, until time.Time) ([]Holding, error) {
+	lots := make(map[string][]lot)
+	index := make(map[string]int)
+	var hs []Holding
+	for _, tx := range txs {
+		if !until.IsZero() && tx.Time.After(until) {
+			break
+		}
+		i, ok := index[tx.Asset]
+		if !ok {
+			i = len(hs)
+			index[tx.Asset] = i
+			hs = append(hs, Holding{Asset: tx.Asset})
+		}
+		h := &hs[i]
+
+		if tx.Side == Buy {
+			cost := (tx.Quantity*tx.Price + tx.Fee) / tx.Quantity
+			lots[tx.Asset] = append(lots[tx.Asset], lot{tx.Quantity, cost})
+			h.Quantity += tx.Quantity
+			h.CostBasis += tx.Quantity * cost
+			if method == AverageCost {
+				lots[tx.Asset] = []lot{{h.Quantity, h.CostBasis / h.Quantity}}
+			}
+			continue
+		}
+
+		if tx.Quantity > h.Quantity*(1+1e-12) {
+			return nil, &assetError{ErrInsufficient, tx.Asset}
+		}
+		var cost float64
+		lots[tx.Asset], cost = consume(lots[tx.Asset], tx.Quantity, method == LIFO)
+		h.Quantity -= tx.Quantity
+		h.CostBasis -= cost
+		h.Realized += tx.Quantity*tx.Price - tx.Fee - cost
+		if h.Quantity <= 0 {
+			h.Quantity, h.CostBasis = 0, 0
+		}
+	}
+	return hs, nil
+}
+
+// consume removes the quantity from the lots and returns its cost.
+func consume(lots []lot, quantity float64, last bool) ([]lot, float64) {
+	var cost float64
+	for quantity > 0 && len(lots) > 0 {
+		i := 0
+		if last {
+			i = len(lots) - 1
+		}
+		l := &lots[i]
+		q := l.quantity
+		if q > quantity {
+			q = quantity
+		}
+		cost += q * l.cost
+		l.quantity -= q
+		quantity -= q
+		if l.quantity <= 0 {
+			if last {
+				lots = lots[:i]
+			} else {
+				lots = lots[1:]
+			}
+		}
+	}
+	return lots, cost
+}
+
+// Position is a valued holding.
+type Position struct {
+	Holding
+	Price      float64 // current price per unit
+	Value      float64 // current value of the amount held
+	Unrealized float64 // unrealized profit and loss
+}
+
+// Valuation is a valued portfolio.
+// All amounts are in the valuation currency.
+type Valuation struct {
+	Currency   string
+	Positions  []Position
+	Value      float64
+	CostBasis  float64
+	Realized   float64
+	Unrealized float64
+	Time       time.Time
+}
+
+// Value values the portfolio using USD prices by asset ID.
+// Holdings without a price are valued at zero.
+func (p *Portfolio) Value(prices map[string]float64) Valuation {
+	v, _ := p.ValueIn(prices, nil, coincap.USDID)
+	return v
+}
+
+// ValueIn values the portfolio using USD prices by asset ID and
+// converts all amounts to the currency. conv may be nil for USD.
+func (p *Portfolio) ValueIn(prices map[string]float64, conv *coincap.Converter, currency string) (Valuation, error) {
+	rate := 1.0
+	if conv != nil {
+		r, err := conv.Rate(coincap.USDID, currency)
+		if err != nil {
+			return Valuation{}, err
+		}
+		rate = r
+	}
+
+	v := Valuation{Currency: currency, Time: time.Now()}
+	for _, h := range p.Holdings() {
+		pos := Position{Holding: h, Price: prices[h.Asset] * rate}
+		pos.CostBasis *= rate
+		pos.Realized *= rate
+		pos.Value = pos.Quantity * pos.Price
+		pos.Unrealized = pos.Value - pos.CostBasis
+		v.Positions = append(v.Positions, pos)
+		v.Value += pos.Value
+		v.CostBasis += pos.CostBasis
+		v.Realized += pos.Realized
+		v.Unrealized += pos.Unrealized
+	}
+	return v, nil
+}
+
+// Prices requests the current USD prices of the portfolio assets.
+func Prices(c *coincap.Client, p *Portfolio) (map[string]float64, error) {
+	ids := p.Assets()
+	prices := make(map[string]float64, len(ids))
+	if len(ids) == 0 {
+		return prices, nil
+	}
+	res, err := c.AssetsByIDs(ids, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range res.Assets {
+		prices[a.ID] = a.PriceUsd
+	}
+	return prices, nil
+}
+
+// Track applies price updates from the stream to the initial prices
+// and calls fn with the new valuation after each update.
+// It blocks until the stream stops and returns the stream error.
+func (p *Portfolio) Track(s *coincap.Stream[map[string]float64], prices map[string]float64, fn func(Valuation)) error {
+	if prices == nil {
+		prices = make(map[string]float64)
+	}
+	for update := range s.DataChannel() {
+		for id, pr := range update {
+			prices[id] = pr
+		}
+		fn(p.Value(prices))
+	}
+	return s.Err()
+}
+
+// Point is a portfolio value at a point in time.
+type Point struct {
+	Time  coincap.Timestamp
+	Value float64 // in the History currency
+}
+
+// History returns the value of the portfolio over time in the currency
+// based on the price history of its assets. conv may be nil for USD;
+// otherwise its current rate is applied to all points.
+func History(c *coincap.Client, p *Portfolio, interval *coincap.IntervalParams,
+	conv *coincap.Converter, currency string) ([]Point, error) {
+	rate := 1.0
+	if conv != nil {
+		r, err := conv.Rate(coincap.USDID, currency)
+		if err != nil {
+			return nil, err
+		}
+		rate = r
+	}
+
+	ids := p.Assets()
+	histories := make(map[string][]coincap.AssetHistory)
+	times := make(map[coincap.Timestamp]struct{})
+	for _, id := range ids {
+		h, _, err := c.AssetHistory(id, interval)
+		if err != nil {
+			return nil, err
+		}
+		histories[id] = h
+		for _, pt := range h {
+			times[pt.Time] = struct{}{}
+		}
+	}
+
+	points := make([]Point, 0, len(times))
+	for t := range times {
+		points = append(points, Point{Time: t})
+	}
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Time < points[j].Time
+	})
+
+	// the transactions are sorted by time, so the quantities are
+	// advanced in a single pass over them.
+	quantity := make(map[string]float64)
+	next := 0
+	for i := range points {
+		t := points[i].Time.Time()
+		for ; next < len(p.txs) && !p.txs[next].Time.After(t); next++ {
+			tx := &p.txs[next]
+			if tx.Side == Buy {
+				quantity[tx.Asset] += tx.Quantity
+			} else if quantity[tx.Asset] -= tx.Quantity; quantity[tx.Asset] <= 0 {
+				quantity[tx.Asset] = 0
+			}
+		}
+		for _, id := range ids {
+			points[i].Value += quantity[id] * priceAt(histories[id], points[i].Time) * rate
+		}
+	}
+	return points, nil
+}
+
+// priceAt returns the last known price at the time.
+func priceAt(h []coincap.AssetHistory, t coincap.Timestamp) float64 {
+	i := sort.Search(len(h), func(i int) bool { return h[i].Time > t })
+	if i == 0 {
+		return 0
+	}
+	return h[i-1].PriceUSD
+}
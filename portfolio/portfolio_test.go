@@ -0,0 +1,112 @@
+package portfolio
+
+import (
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/karalef/coincap"
+)
+
+func testPortfolio(t *testing.T, method Method) *Portfolio {
+	p := New(method)
+	day := func(d int) time.Time { return time.Date(2022, 1, d, 0, 0, 0, 0, time.UTC) }
+	txs := []Transaction{
+		{Asset: "bitcoin", Side: Buy, Quantity: 1, Price: 100, Time: day(1)},
+		{Asset: "bitcoin", Side: Buy, Quantity: 1, Price: 200, Time: day(2)},
+		{Asset: "bitcoin", Side: Sell, Quantity: 1, Price: 300, Time: day(3)},
+	}
+	for _, tx := range txs {
+		if err := p.Add(tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return p
+}
+
+func TestMethods(t *testing.T) {
+	tests := []struct {
+		method   Method
+		realized float64
+		basis    float64
+	}{
+		{FIFO, 200, 200},
+		{LIFO, 100, 100},
+		{AverageCost, 150, 150},
+	}
+	for _, tt := range tests {
+		p := testPortfolio(t, tt.method)
+		v := p.Value(map[string]float64{"bitcoin": 400})
+		if math.Abs(v.Realized-tt.realized) > 1e-9 || math.Abs(v.CostBasis-tt.basis) > 1e-9 {
+			t.Errorf("method %d: unexpected valuation %+v", tt.method, v)
+		}
+		if math.Abs(v.Value-400) > 1e-9 || math.Abs(v.Unrealized-(400-tt.basis)) > 1e-9 {
+			t.Errorf("method %d: unexpected value %+v", tt.method, v)
+		}
+	}
+}
+
+func TestInsufficient(t *testing.T) {
+	p := testPortfolio(t, FIFO)
+	err := p.Add(Transaction{Asset: "bitcoin", Side: Sell, Quantity: 2, Price: 1, Time: time.Now()})
+	if !errors.Is(err, ErrInsufficient) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(p.Transactions()) != 3 {
+		t.Fatal("invalid transaction was added")
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestHistory(t *testing.T) {
+	const body = `{"data":[` +
+		`{"priceUsd":"100","time":1640995200000},{"priceUsd":"200","time":1641081600000},` +
+		`{"priceUsd":"300","time":1641168000000},{"priceUsd":"400","time":1641254400000}],"timestamp":1}`
+	c := coincap.NewClient(&http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if !strings.HasSuffix(r.URL.Path, "/assets/bitcoin/history") {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(body)), Request: r}, nil
+	})}, nil)
+
+	p := testPortfolio(t, FIFO)
+	conv := coincap.NewConverter([]coincap.Rate{
+		{ID: coincap.USDID, Symbol: coincap.USDSymbol, RateUSD: 1},
+		{ID: "euro", Symbol: "EUR", RateUSD: 2},
+	}, 0)
+	tests := []struct {
+		conv     *coincap.Converter
+		currency string
+		want     []float64
+	}{
+		{nil, coincap.USDID, []float64{100, 400, 300, 400}},
+		{conv, "EUR", []float64{50, 200, 150, 200}},
+	}
+	for _, tt := range tests {
+		points, err := History(&c, p, nil, tt.conv, tt.currency)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(points) != len(tt.want) {
+			t.Fatalf("%s: expected %d points, got %d", tt.currency, len(tt.want), len(points))
+		}
+		for i, pt := range points {
+			if math.Abs(pt.Value-tt.want[i]) > 1e-9 {
+				t.Errorf("%s: point %d value %v, want %v", tt.currency, i, pt.Value, tt.want[i])
+			}
+		}
+	}
+
+	if _, err := History(&c, p, nil, conv, "XXX"); !errors.Is(err, coincap.ErrUnknownCurrency) {
+		t.Errorf("expected ErrUnknownCurrency, got %v", err)
+	}
+}
@@ -0,0 +1,222 @@
+package coincap
+
+import (
+	"cmp"
+	"sort"
+)
+
+// Cond is a query condition.
+type Cond[T any] func(*T) bool
+
+// Eq matches items with the field equal to the value.
+func Eq[T any, V comparable](field func(*T) V, v V) Cond[T] {
+	return func(t *T) bool { return field(t) == v }
+}
+
+// Gt matches items with the field greater than the value.
+func Gt[T any, V cmp.Ordered](field func(*T) V, v V) Cond[T] {
+	return func(t *T) bool { return field(t) > v }
+}
+
+// Ge matches items with the field greater than or equal to the value.
+func Ge[T any, V cmp.Ordered](field func(*T) V, v V) Cond[T] {
+	return func(t *T) bool { return field(t) >= v }
+}
+
+// Lt matches items with the field less than the value.
+func Lt[T any, V cmp.Ordered](field func(*T) V, v V) Cond[T] {
+	return func(t *T) bool { return field(t) < v }
+}
+
+// Le matches items with the field less than or equal to the value.
+func Le[T any, V cmp.Ordered](field func(*T) V, v V) Cond[T] {
+	return func(t *T) bool { return field(t) <= v }
+}
+
+// In matches items with the field equal to any of the values.
+func In[T any, V comparable](field func(*T) V, values ...V) Cond[T] {
+	set := make(map[V]struct{}, len(values))
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+	return func(t *T) bool {
+		_, ok := set[field(t)]
+		return ok
+	}
+}
+
+// Not negates the condition.
+func Not[T any](c Cond[T]) Cond[T] {
+	return func(t *T) bool { return !c(t) }
+}
+
+// Or matches items matching any of the conditions.
+func Or[T any](conds ...Cond[T]) Cond[T] {
+	return func(t *T) bool {
+		for _, c := range conds {
+			if c(t) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+type sortKey[T any] struct {
+	key  func(*T) float64
+	desc bool
+}
+
+// Query filters, sorts and paginates lists on the client side.
+// The zero value matches all items.
+type Query[T any] struct {
+	conds  []Cond[T]
+	sort   []sortKey[T]
+	offset int
+	limit  int
+}
+
+// NewQuery creates a new query.
+func NewQuery[T any]() *Query[T] {
+	return &Query[T]{}
+}
+
+// Where adds the conditions. An item matches if it matches all of them.
+func (q *Query[T]) Where(conds ...Cond[T]) *Query[T] {
+	q.conds = append(q.conds, conds...)
+	return q
+}
+
+// SortBy adds a sort key. Keys added earlier take precedence.
+func (q *Query[T]) SortBy(key func(*T) float64, desc bool) *Query[T] {
+	q.sort = append(q.sort, sortKey[T]{key, desc})
+	return q
+}
+
+// Offset skips the first n matching items. Negative n is treated as zero.
+func (q *Query[T]) Offset(n int) *Query[T] {
+	q.offset = max(n, 0)
+	return q
+}
+
+// Limit sets the maximum number of results (unlimited if not positive).
+func (q *Query[T]) Limit(n int) *Query[T] {
+	q.limit = max(n, 0)
+	return q
+}
+
+// Match reports whether the item matches the conditions.
+func (q *Query[T]) Match(t *T) bool {
+	for _, c := range q.conds {
+		if !c(t) {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply returns the page of the matching items in the query order.
+// The items are not modified.
+func (q *Query[T]) Apply(items []T) []T {
+	out := make([]T, 0)
+	for i := range items {
+		if q.Match(&items[i]) {
+			out = append(out, items[i])
+		}
+	}
+	if len(q.sort) > 0 {
+		sort.SliceStable(out, func(i, j int) bool {
+			for _, k := range q.sort {
+				a, b := k.key(&out[i]), k.key(&out[j])
+				if a == b {
+					continue
+				}
+				return a < b != k.desc
+			}
+			return false
+		})
+	}
+	if q.offset >= len(out) {
+		return out[:0]
+	}
+	out = out[q.offset:]
+	if q.limit > 0 && q.limit < len(out) {
+		out = out[:q.limit]
+	}
+	return out
+}
+
+// enough reports whether the number of matched items fills the page
+// so no more items need to be fetched.
+func (q *Query[T]) enough(matched int) bool {
+	return len(q.sort) == 0 && q.limit > 0 && matched >= q.offset+q.limit
+}
+
+// maxPage is the maximum page size of the API.
+const maxPage = 2000
+
+// fetchQuery fetches pages until the query page is filled or there are no
+// more items. Sorted queries always fetch all pages.
+func fetchQuery[T any](q *Query[T], page func(trim *TrimParams) ([]T, error)) ([]T, error) {
+	if q == nil {
+		q = NewQuery[T]()
+	}
+	var all []T
+	matched := 0
+	trim := TrimParams{Limit: maxPage}
+	for {
+		items, err := page(&trim)
+		if err != nil {
+			return nil, err
+		}
+		for i := range items {
+			if q.Match(&items[i]) {
+				all = append(all, items[i])
+				matched++
+			}
+		}
+		if uint(len(items)) < trim.Limit || q.enough(matched) {
+			return q.Apply(all), nil
+		}
+		trim.Offset += trim.Limit
+	}
+}
+
+// QueryAssets fetches the assets matching the search and returns the page
+// of the query results.
+func (c *Client) QueryAssets(search string, q *Query[Asset]) ([]Asset, error) {
+	return fetchQuery(q, func(trim *TrimParams) ([]Asset, error) {
+		a, _, err := c.AssetsSearch(search, trim)
+		return a, err
+	})
+}
+
+// QueryMarkets fetches the markets matching the params and returns the page
+// of the query results.
+func (c *Client) QueryMarkets(params MarketsRequest, q *Query[Market]) ([]Market, error) {
+	return fetchQuery(q, func(trim *TrimParams) ([]Market, error) {
+		m, _, err := c.Markets(params, trim)
+		return m, err
+	})
+}
+
+// QueryAssetMarkets fetches the markets of the asset and returns the page
+// of the query results.
+func (c *Client) QueryAssetMarkets(id string, q *Query[AssetMarket]) ([]AssetMarket, error) {
+	return fetchQuery(q, func(trim *TrimParams) ([]AssetMarket, error) {
+		m, _, err := c.AssetMarkets(id, trim)
+		return m, err
+	})
+}
+
+// QueryExchanges fetches the exchanges and returns the page of the query results.
+func (c *Client) QueryExchanges(q *Query[Exchange]) ([]Exchange, error) {
+	if q == nil {
+		q = NewQuery[Exchange]()
+	}
+	e, _, err := c.Exchanges()
+	if err != nil {
+		return nil, err
+	}
+	return q.Apply(e), nil
+}
@@ -0,0 +1,60 @@
+package coincap
+
+import "testing"
+
+func TestQuery(t *testing.T) {
+	markets := []Market{
+		{ExchangeID: "a", QuoteSymbol: "USDT", VolumeUsd24Hr: 10, Rank: 3},
+		{ExchangeID: "b", QuoteSymbol: "BTC", VolumeUsd24Hr: 50, Rank: 1},
+		{ExchangeID: "c", QuoteSymbol: "USD", VolumeUsd24Hr: 30, Rank: 2},
+		{ExchangeID: "d", QuoteSymbol: "USDT", VolumeUsd24Hr: 40, Rank: 4},
+		{ExchangeID: "e", QuoteSymbol: "EUR", VolumeUsd24Hr: 90, Rank: 5},
+	}
+	quote := func(m *Market) string { return m.QuoteSymbol }
+	volume := func(m *Market) float64 { return m.VolumeUsd24Hr }
+
+	got := NewQuery[Market]().
+		Where(In(quote, "USD", "USDT", "BTC"), Gt(volume, 20)).
+		SortBy(volume, true).
+		Offset(1).Limit(2).
+		Apply(markets)
+	if len(got) != 2 || got[0].ExchangeID != "d" || got[1].ExchangeID != "c" {
+		t.Errorf("unexpected result %+v", got)
+	}
+
+	if got := NewQuery[Market]().Offset(-1).Limit(-1).Apply(markets); len(got) != len(markets) {
+		t.Errorf("negative offset and limit must be ignored, got %d items", len(got))
+	}
+
+	got = NewQuery[Market]().Where(Le(func(m *Market) int { return m.Rank }, 2)).Apply(markets)
+	if len(got) != 2 || got[0].ExchangeID != "b" {
+		t.Errorf("unexpected result %+v", got)
+	}
+}
+
+func TestFetchQuery(t *testing.T) {
+	pages := 0
+	page := func(trim *TrimParams) ([]Asset, error) {
+		pages++
+		items := make([]Asset, maxPage)
+		for i := range items {
+			items[i].Rank = int(trim.Offset) + i + 1
+		}
+		if pages == 3 {
+			items = items[:10]
+		}
+		return items, nil
+	}
+	rank := func(a *Asset) int { return a.Rank }
+
+	got, _ := fetchQuery(NewQuery[Asset]().Where(Gt(rank, 1990)).Limit(20), page)
+	if pages != 2 || len(got) != 20 || got[0].Rank != 1991 {
+		t.Errorf("unexpected result: %d pages, %d items", pages, len(got))
+	}
+
+	pages = 0
+	got, _ = fetchQuery(NewQuery[Asset]().SortBy(func(a *Asset) float64 { return float64(a.Rank) }, true).Limit(1), page)
+	if pages != 3 || len(got) != 1 || got[0].Rank != 2*maxPage+10 {
+		t.Errorf("sorted query must fetch all pages: %d pages, %+v", pages, got)
+	}
+}
@@ -0,0 +1,273 @@
+package coincap
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RecordOptions contains stream recording parameters.
+type RecordOptions struct {
+	Dir       string        // directory of the log files
+	Prefix    string        // file name prefix ("stream" if empty)
+	MaxSize   int64         // rotate after this many uncompressed bytes (disabled if zero)
+	MaxAge    time.Duration // rotate after this duration (disabled if zero)
+	NoGzip    bool          // write plain NDJSON instead of gzip-compressed
+	Overwrite bool          // truncate existing files with the same name
+}
+
+// record is a single line of the stream log.
+type record[T any] struct {
+	Time Timestamp `json:"t"` // receive time
+	Data T         `json:"d"`
+}
+
+// Recorder writes stream values into timestamped NDJSON log files.
+//
+// It is safe for concurrent use.
+type Recorder[T any] struct {
+	opts RecordOptions
+
+	mu      sync.Mutex
+	file    *os.File
+	gz      *gzip.Writer
+	buf     *bufio.Writer
+	size    int64
+	opened  time.Time
+	counter int
+}
+
+// NewRecorder creates a recorder. Files are created on the first write.
+func NewRecorder[T any](opts RecordOptions) (*Recorder[T], error) {
+	if opts.Prefix == "" {
+		opts.Prefix = "stream"
+	}
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Recorder[T]{opts: opts}, nil
+}
+
+func (r *Recorder[T]) open(now time.Time) error {
+	r.counter++
+	name := r.opts.Prefix + "-" + now.UTC().Format("20060102T150405.000") + "-" + pad(r.counter, 6) + ".ndjson"
+	if !r.opts.NoGzip {
+		name += ".gz"
+	}
+	flags := os.O_CREATE | os.O_WRONLY | os.O_EXCL
+	if r.opts.Overwrite {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	}
+	f, err := os.OpenFile(filepath.Join(r.opts.Dir, name), flags, 0o644)
+	if err != nil {
+		return err
+	}
+	r.file, r.size, r.opened = f, 0, now
+	var w io.Writer = f
+	if !r.opts.NoGzip {
+		r.gz = gzip.NewWriter(f)
+		w = r.gz
+	}
+	r.buf = bufio.NewWriter(w)
+	return nil
+}
+
+// pad formats the number with leading zeros.
+func pad(n, width int) string {
+	s := strconv.Itoa(n)
+	for len(s) < width {
+		s = "0" + s
+	}
+	return s
+}
+
+func (r *Recorder[T]) closeFile() error {
+	if r.file == nil {
+		return nil
+	}
+	err := r.buf.Flush()
+	if r.gz != nil {
+		if e := r.gz.Close(); err == nil {
+			err = e
+		}
+		r.gz = nil
+	}
+	if e := r.file.Close(); err == nil {
+		err = e
+	}
+	r.file, r.buf = nil, nil
+	return err
+}
+
+// Write writes the value with the current time.
+func (r *Recorder[T]) Write(v T) error {
+	return r.WriteAt(v, time.Now())
+}
+
+// WriteAt writes the value with the given receive time.
+func (r *Recorder[T]) WriteAt(v T, t time.Time) error {
+	line, err := json.Marshal(record[T]{Time: TimestampFromTime(t), Data: v})
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file != nil && (r.opts.MaxSize > 0 && r.size >= r.opts.MaxSize ||
+		r.opts.MaxAge > 0 && t.Sub(r.opened) >= r.opts.MaxAge) {
+		if err := r.closeFile(); err != nil {
+			return err
+		}
+	}
+	if r.file == nil {
+		if err := r.open(t); err != nil {
+			return err
+		}
+	}
+	r.buf.Write(line)
+	err = r.buf.WriteByte('\n')
+	r.size += int64(len(line)) + 1
+	return err
+}
+
+// Flush flushes buffered data to the current file.
+func (r *Recorder[T]) Flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	if err := r.buf.Flush(); err != nil {
+		return err
+	}
+	if r.gz != nil {
+		return r.gz.Flush()
+	}
+	return nil
+}
+
+// Close flushes and closes the current file.
+func (r *Recorder[T]) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.closeFile()
+}
+
+// Tee returns a stream delivering the same values as the source stream
+// while writing them to the recorder. Write errors stop the stream.
+// The recorder is not closed when the stream stops.
+func Tee[T any](src *Stream[T], r *Recorder[T]) *Stream[T] {
+	var werr error
+	return pipeErr(src, func(v T, send func(T) bool) bool {
+		if werr = r.Write(v); werr != nil {
+			return false
+		}
+		return send(v)
+	}, func() error { return werr })
+}
+
+// ReplaySpeed is a replay speed multiplier.
+// Zero replays as fast as possible.
+type ReplaySpeed float64
+
+// Replay speeds.
+const (
+	AsFastAsPossible ReplaySpeed = 0
+	OriginalSpeed    ReplaySpeed = 1
+)
+
+// RecordFiles returns the log files with the prefix in recording order.
+func RecordFiles(dir, prefix string) ([]string, error) {
+	if prefix == "" {
+		prefix = "stream"
+	}
+	files, err := filepath.Glob(filepath.Join(dir, prefix+"-*.ndjson*"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Replay streams the values recorded in the files in the given order.
+// Values are delivered with the recorded time gaps divided by the speed.
+func Replay[T any](speed ReplaySpeed, files ...string) *Stream[T] {
+	s := newStream[T]()
+	go func() {
+		var (
+			err   error
+			first Timestamp
+			start time.Time
+		)
+	files:
+		for _, name := range files {
+			var ok bool
+			ok, err = replayFile(s, name, func(ts Timestamp) bool {
+				if speed <= 0 {
+					return true
+				}
+				if start.IsZero() {
+					first, start = ts, time.Now()
+					return true
+				}
+				due := start.Add(time.Duration(float64(ts-first) * float64(time.Millisecond) / float64(speed)))
+				timer := time.NewTimer(time.Until(due))
+				defer timer.Stop()
+				select {
+				case <-s.stop:
+					return false
+				case <-timer.C:
+					return true
+				}
+			})
+			if err != nil || !ok {
+				break files
+			}
+		}
+		s.done(err)
+	}()
+	return s
+}
+
+// replayFile sends the values of the file after waiting for each.
+// It returns false if the stream was closed.
+func replayFile[T any](s *Stream[T], name string, wait func(Timestamp) bool) (bool, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	var r io.Reader = bufio.NewReader(f)
+	if filepath.Ext(name) == ".gz" {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return false, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	dec := json.NewDecoder(r)
+	for {
+		var rec record[T]
+		err := dec.Decode(&rec)
+		if errors.Is(err, io.EOF) {
+			return true, nil
+		}
+		if err != nil {
+			return false, errors.New(name + ": " + err.Error())
+		}
+		if !wait(rec.Time) || !s.send(rec.Data) {
+			return false, nil
+		}
+	}
+}
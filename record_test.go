@@ -0,0 +1,53 @@
+package coincap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecordReplay(t *testing.T) {
+	dir := t.TempDir()
+	r, err := NewRecorder[*Trade](RecordOptions{Dir: dir, Prefix: "trades", MaxSize: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		tr := &Trade{Exchange: "x", Base: "bitcoin", Quote: "tether", Price: float64(i), Direction: Buy}
+		if err := r.WriteAt(tr, start.Add(time.Duration(i)*10*time.Millisecond)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := RecordFiles(dir, "trades")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) < 2 {
+		t.Fatalf("expected rotated files, got %v", files)
+	}
+
+	for _, speed := range []ReplaySpeed{AsFastAsPossible, 2} {
+		begin := time.Now()
+		s := Replay[*Trade](speed, files...)
+		var n int
+		for tr := range s.DataChannel() {
+			if tr.Price != float64(n) || tr.Direction != Buy {
+				t.Fatalf("unexpected trade %+v", tr)
+			}
+			n++
+		}
+		if err := s.Err(); err != nil {
+			t.Fatal(err)
+		}
+		if n != 5 {
+			t.Fatalf("replayed %d trades, want 5", n)
+		}
+		if speed == 2 && time.Since(begin) < 20*time.Millisecond {
+			t.Errorf("replay was too fast: %v", time.Since(begin))
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package coincap
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// SpreadOptions contains market filtering parameters for spread scanning.
+type SpreadOptions struct {
+	MaxAge       time.Duration // skip markets not updated for longer than this (no limit if zero)
+	MinVolumeUsd float64       // skip markets with lower 24 hour volume
+	MinPercent   float64       // report only spreads of at least this percent
+}
+
+// Spread contains the price difference of a pair between exchanges.
+type Spread struct {
+	BaseID  string
+	QuoteID string
+	Low     Market  // market with the lowest price
+	High    Market  // market with the highest price
+	Spread  float64 // difference between the highest and the lowest quote price
+	Percent float64 // spread in percent of the lowest price
+}
+
+type pairKey struct {
+	base, quote string
+}
+
+func (o *SpreadOptions) allow(m *Market, now time.Time) bool {
+	if o == nil {
+		return m.PriceQuote > 0
+	}
+	if o.MaxAge > 0 && m.Updated != 0 && now.Sub(m.Updated.Time()) > o.MaxAge {
+		return false
+	}
+	return m.PriceQuote > 0 && m.VolumeUsd24Hr >= o.MinVolumeUsd
+}
+
+func (o *SpreadOptions) minPercent() float64 {
+	if o == nil {
+		return 0
+	}
+	return o.MinPercent
+}
+
+// spread computes the spread between the markets of the same pair.
+func spread(markets []*Market, opts *SpreadOptions, now time.Time) (Spread, bool) {
+	var low, high *Market
+	for _, m := range markets {
+		if !opts.allow(m, now) {
+			continue
+		}
+		if low == nil || m.PriceQuote < low.PriceQuote {
+			low = m
+		}
+		if high == nil || m.PriceQuote > high.PriceQuote {
+			high = m
+		}
+	}
+	if low == nil || low == high {
+		return Spread{}, false
+	}
+	s := Spread{
+		BaseID:  low.BaseID,
+		QuoteID: low.QuoteID,
+		Low:     *low,
+		High:    *high,
+		Spread:  high.PriceQuote - low.PriceQuote,
+	}
+	s.Percent = s.Spread / low.PriceQuote * 100
+	return s, s.Percent >= opts.minPercent()
+}
+
+// FindSpreads groups markets by base and quote and returns the spreads
+// between exchanges sorted by percent in descending order.
+func FindSpreads(markets []Market, opts *SpreadOptions) []Spread {
+	groups := make(map[pairKey][]*Market)
+	for i := range markets {
+		m := &markets[i]
+		k := pairKey{m.BaseID, m.QuoteID}
+		groups[k] = append(groups[k], m)
+	}
+
+	now := time.Now()
+	var spreads []Spread
+	for _, g := range groups {
+		if s, ok := spread(g, opts, now); ok {
+			spreads = append(spreads, s)
+		}
+	}
+	sort.Slice(spreads, func(i, j int) bool {
+		return spreads[i].Percent > spreads[j].Percent
+	})
+	return spreads
+}
+
+// Market converts asset market into market.
+// The USD price is used as the quote price.
+func (m AssetMarket) Market() Market {
+	return Market{
+		ExchangeID:    m.ExchangeID,
+		BaseID:        m.BaseID,
+		BaseSymbol:    m.BaseSymbol,
+		QuoteID:       m.QuoteID,
+		QuoteSymbol:   m.QuoteSymbol,
+		PriceQuote:    m.PriceUsd,
+		PriceUsd:      m.PriceUsd,
+		VolumeUsd24Hr: m.VolumeUsd24Hr,
+	}
+}
+
+// FindAssetMarketSpreads is like FindSpreads but for asset markets.
+// Asset markets have no update time so MaxAge is ignored.
+func FindAssetMarketSpreads(markets []AssetMarket, opts *SpreadOptions) []Spread {
+	ms := make([]Market, len(markets))
+	for i := range markets {
+		ms[i] = markets[i].Market()
+	}
+	return FindSpreads(ms, opts)
+}
+
+// SpreadScanner keeps the latest market prices and computes
+// spreads as trades arrive.
+//
+// It is safe for concurrent use.
+type SpreadScanner struct {
+	opts SpreadOptions
+
+	mu      sync.Mutex
+	markets map[pairKey]map[string]*Market // by exchange
+}
+
+// NewSpreadScanner creates a spread scanner from the markets snapshot.
+func NewSpreadScanner(markets []Market, opts *SpreadOptions) *SpreadScanner {
+	s := &SpreadScanner{markets: make(map[pairKey]map[string]*Market)}
+	if opts != nil {
+		s.opts = *opts
+	}
+	for _, m := range markets {
+		s.set(m)
+	}
+	return s
+}
+
+func (s *SpreadScanner) set(m Market) {
+	k := pairKey{m.BaseID, m.QuoteID}
+	g, ok := s.markets[k]
+	if !ok {
+		g = make(map[string]*Market)
+		s.markets[k] = g
+	}
+	g[m.ExchangeID] = &m
+}
+
+// Update applies the trade price to the corresponding market and returns
+// the resulting spread of its pair if it satisfies the options.
+// Trades of unknown markets are added with zero volume.
+func (s *SpreadScanner) Update(t *Trade) (Spread, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := pairKey{t.Base, t.Quote}
+	m, ok := s.markets[k][t.Exchange]
+	if !ok {
+		s.set(Market{ExchangeID: t.Exchange, BaseID: t.Base, QuoteID: t.Quote})
+		m = s.markets[k][t.Exchange]
+	}
+	m.PriceQuote = t.Price
+	if t.PriceUSD != 0 {
+		m.PriceUsd = t.PriceUSD
+	}
+	m.Updated = t.Timestamp
+
+	group := make([]*Market, 0, len(s.markets[k]))
+	for _, m := range s.markets[k] {
+		group = append(group, m)
+	}
+	return spread(group, &s.opts, time.Now())
+}
+
+// Spreads returns all current spreads sorted by percent in descending order.
+func (s *SpreadScanner) Spreads() []Spread {
+	s.mu.Lock()
+	var markets []Market
+	for _, g := range s.markets {
+		for _, m := range g {
+			markets = append(markets, *m)
+		}
+	}
+	s.mu.Unlock()
+	return FindSpreads(markets, &s.opts)
+}
+
+// WatchSpreads subscribes to trades of the exchanges and streams
+// the spreads updated by each trade.
+func (c *Client) WatchSpreads(scanner *SpreadScanner, exchanges ...string) (*Stream[Spread], error) {
+	trades, err := c.TradesMulti(nil, exchanges...)
+	if err != nil {
+		return nil, err
+	}
+	return pipe(trades, func(t *Trade, send func(Spread) bool) bool {
+		sp, ok := scanner.Update(t)
+		return !ok || send(sp)
+	}), nil
+}
@@ -0,0 +1,37 @@
+package coincap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFindSpreads(t *testing.T) {
+	now := Timestamp(time.Now().UnixMilli())
+	old := Timestamp(time.Now().Add(-time.Hour).UnixMilli())
+	markets := []Market{
+		{ExchangeID: "a", BaseID: "bitcoin", QuoteID: "tether", PriceQuote: 20000, VolumeUsd24Hr: 1e6, Updated: now},
+		{ExchangeID: "b", BaseID: "bitcoin", QuoteID: "tether", PriceQuote: 20200, VolumeUsd24Hr: 1e6, Updated: now},
+		{ExchangeID: "c", BaseID: "bitcoin", QuoteID: "tether", PriceQuote: 25000, VolumeUsd24Hr: 1e6, Updated: old},
+		{ExchangeID: "d", BaseID: "bitcoin", QuoteID: "tether", PriceQuote: 10000, VolumeUsd24Hr: 10, Updated: now},
+		{ExchangeID: "a", BaseID: "ethereum", QuoteID: "tether", PriceQuote: 1500, VolumeUsd24Hr: 1e6, Updated: now},
+	}
+
+	spreads := FindSpreads(markets, &SpreadOptions{MaxAge: time.Minute, MinVolumeUsd: 1000})
+	if len(spreads) != 1 {
+		t.Fatalf("expected 1 spread, got %d", len(spreads))
+	}
+	s := spreads[0]
+	if s.Low.ExchangeID != "a" || s.High.ExchangeID != "b" || !almostEqual(s.Percent, 1) {
+		t.Errorf("unexpected spread %+v", s)
+	}
+
+	if got := FindSpreads(markets, &SpreadOptions{MaxAge: time.Minute, MinVolumeUsd: 1000, MinPercent: 2}); len(got) != 0 {
+		t.Errorf("expected no spreads, got %+v", got)
+	}
+
+	sc := NewSpreadScanner(markets[:2], nil)
+	s, ok := sc.Update(&Trade{Exchange: "a", Base: "bitcoin", Quote: "tether", Price: 19000, Timestamp: now})
+	if !ok || s.Low.PriceQuote != 19000 || s.High.ExchangeID != "b" {
+		t.Errorf("unexpected spread after update %+v", s)
+	}
+}
@@ -0,0 +1,227 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/karalef/coincap"
+)
+
+// FileStore is a Store keeping every series in a JSON file
+// inside the directory.
+//
+// It is safe for concurrent use within a process.
+type FileStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// OpenFileStore opens the file store in the directory, creating it if needed.
+func OpenFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+type seriesFile struct {
+	Ranges  []Range                `json:"ranges"`
+	Candles []coincap.Candle       `json:"candles,omitempty"`
+	History []coincap.AssetHistory `json:"history,omitempty"`
+}
+
+func (f *FileStore) path(s Series) string {
+	iv := s.Interval.String() + ".json"
+	if s.Kind == History {
+		return filepath.Join(f.dir, "history", url.PathEscape(s.Asset), iv)
+	}
+	return filepath.Join(f.dir, "candles", url.PathEscape(s.Exchange),
+		url.PathEscape(s.Base), url.PathEscape(s.Quote), iv)
+}
+
+func (f *FileStore) load(s Series) (*seriesFile, error) {
+	var sf seriesFile
+	b, err := os.ReadFile(f.path(s))
+	if errors.Is(err, fs.ErrNotExist) {
+		return &sf, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sf, json.Unmarshal(b, &sf)
+}
+
+func (f *FileStore) save(s Series, sf *seriesFile) error {
+	b, err := json.Marshal(sf)
+	if err != nil {
+		return err
+	}
+	return writeFile(f.path(s), b)
+}
+
+// writeFile atomically replaces the file contents.
+func writeFile(p string, b []byte) error {
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+// PutCandles is Store implementation.
+func (f *FileStore) PutCandles(s Series, r Range, candles []coincap.Candle) error {
+	if s.Kind != Candles {
+		return ErrKind
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sf, err := f.load(s)
+	if err != nil {
+		return err
+	}
+	sf.Candles = mergePoints(sf.Candles, candles, func(c *coincap.Candle) coincap.Timestamp { return c.Period })
+	sf.Ranges = Merge(append(sf.Ranges, r))
+	return f.save(s, sf)
+}
+
+// Candles is Store implementation.
+func (f *FileStore) Candles(s Series, r Range) ([]coincap.Candle, error) {
+	if s.Kind != Candles {
+		return nil, ErrKind
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sf, err := f.load(s)
+	if err != nil {
+		return nil, err
+	}
+	return within(sf.Candles, r, func(c *coincap.Candle) coincap.Timestamp { return c.Period }), nil
+}
+
+// PutHistory is Store implementation.
+func (f *FileStore) PutHistory(s Series, r Range, history []coincap.AssetHistory) error {
+	if s.Kind != History {
+		return ErrKind
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sf, err := f.load(s)
+	if err != nil {
+		return err
+	}
+	sf.History = mergePoints(sf.History, history, func(h *coincap.AssetHistory) coincap.Timestamp { return h.Time })
+	sf.Ranges = Merge(append(sf.Ranges, r))
+	return f.save(s, sf)
+}
+
+// History is Store implementation.
+func (f *FileStore) History(s Series, r Range) ([]coincap.AssetHistory, error) {
+	if s.Kind != History {
+		return nil, ErrKind
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sf, err := f.load(s)
+	if err != nil {
+		return nil, err
+	}
+	return within(sf.History, r, func(h *coincap.AssetHistory) coincap.Timestamp { return h.Time }), nil
+}
+
+// Covered is Store implementation.
+func (f *FileStore) Covered(s Series) ([]Range, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sf, err := f.load(s)
+	if err != nil {
+		return nil, err
+	}
+	return sf.Ranges, nil
+}
+
+// mergePoints merges the points ordered by time.
+// New points replace the old ones with the same time.
+func mergePoints[T any](old, add []T, ts func(*T) coincap.Timestamp) []T {
+	m := make(map[coincap.Timestamp]T, len(old)+len(add))
+	for i := range old {
+		m[ts(&old[i])] = old[i]
+	}
+	for i := range add {
+		m[ts(&add[i])] = add[i]
+	}
+	out := make([]T, 0, len(m))
+	for _, p := range m {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool { return ts(&out[i]) < ts(&out[j]) })
+	return out
+}
+
+// within returns the points of the ordered slice within the range.
+func within[T any](points []T, r Range, ts func(*T) coincap.Timestamp) []T {
+	i := sort.Search(len(points), func(i int) bool { return ts(&points[i]) >= r.Start })
+	j := sort.Search(len(points), func(i int) bool { return ts(&points[i]) >= r.End })
+	return append([]T(nil), points[i:j]...)
+}
+
+type snapshotFile struct {
+	Time coincap.Timestamp `json:"time"`
+	Data json.RawMessage   `json:"data"`
+}
+
+func (f *FileStore) snapshotPath(name string) string {
+	return filepath.Join(f.dir, "snapshots", url.PathEscape(name)+".json")
+}
+
+// PutSnapshot stores the JSON-encoded value under the name
+// replacing the previous one.
+func (f *FileStore) PutSnapshot(name string, ts coincap.Timestamp, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(snapshotFile{Time: ts, Data: data})
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return writeFile(f.snapshotPath(name), b)
+}
+
+// Snapshot decodes the snapshot stored under the name into v
+// and returns its time. It returns zero time if there is no snapshot.
+func (f *FileStore) Snapshot(name string, v any) (coincap.Timestamp, error) {
+	f.mu.Lock()
+	b, err := os.ReadFile(f.snapshotPath(name))
+	f.mu.Unlock()
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	var sf snapshotFile
+	if err = json.Unmarshal(b, &sf); err != nil {
+		return 0, err
+	}
+	return sf.Time, json.Unmarshal(sf.Data, v)
+}
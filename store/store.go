@@ -0,0 +1,117 @@
+// Package store provides local storage of CoinCap time series
+// (candles and asset price history) that keeps track of the stored
+// time ranges so only missing windows are requested from the API.
+package store
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/karalef/coincap"
+)
+
+// Kind is a series kind.
+type Kind uint8
+
+// Series kinds.
+const (
+	Candles Kind = iota
+	History
+)
+
+// Series identifies a time series.
+type Series struct {
+	Kind     Kind
+	Exchange string // candles only
+	Base     string // candles only
+	Quote    string // candles only
+	Asset    string // history only
+	Interval coincap.Interval
+}
+
+// CandleSeries returns the candles series of the market.
+func CandleSeries(exchange, base, quote string, interval coincap.Interval) Series {
+	return Series{Kind: Candles, Exchange: exchange, Base: base, Quote: quote, Interval: interval}
+}
+
+// HistorySeries returns the price history series of the asset.
+func HistorySeries(asset string, interval coincap.Interval) Series {
+	return Series{Kind: History, Asset: asset, Interval: interval}
+}
+
+// Range is a half-open time range [Start, End).
+type Range struct {
+	Start coincap.Timestamp `json:"start"`
+	End   coincap.Timestamp `json:"end"`
+}
+
+// Empty reports whether the range is empty.
+func (r Range) Empty() bool {
+	return r.End <= r.Start
+}
+
+// TimeRange returns the range between the times.
+func TimeRange(start, end time.Time) Range {
+	return Range{coincap.TimestampFromTime(start), coincap.TimestampFromTime(end)}
+}
+
+// Store stores time series data.
+// Put methods store the points and mark the range as covered
+// even if there are no points in it.
+type Store interface {
+	PutCandles(s Series, r Range, candles []coincap.Candle) error
+	Candles(s Series, r Range) ([]coincap.Candle, error)
+	PutHistory(s Series, r Range, history []coincap.AssetHistory) error
+	History(s Series, r Range) ([]coincap.AssetHistory, error)
+	Covered(s Series) ([]Range, error)
+}
+
+// errors.
+var (
+	ErrKind = errors.New("store: wrong series kind")
+)
+
+// Merge returns the union of the ranges sorted by start.
+func Merge(ranges []Range) []Range {
+	rs := make([]Range, 0, len(ranges))
+	for _, r := range ranges {
+		if !r.Empty() {
+			rs = append(rs, r)
+		}
+	}
+	sort.Slice(rs, func(i, j int) bool { return rs[i].Start < rs[j].Start })
+	out := rs[:0]
+	for _, r := range rs {
+		if n := len(out); n > 0 && r.Start <= out[n-1].End {
+			if r.End > out[n-1].End {
+				out[n-1].End = r.End
+			}
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// Missing returns the parts of the wanted range not covered by the ranges.
+func Missing(covered []Range, want Range) []Range {
+	var missing []Range
+	cur := want.Start
+	for _, r := range Merge(covered) {
+		if r.End <= cur {
+			continue
+		}
+		if r.Start >= want.End {
+			break
+		}
+		if r.Start > cur {
+			missing = append(missing, Range{cur, r.Start})
+		}
+		cur = r.End
+	}
+	if cur < want.End {
+		missing = append(missing, Range{cur, want.End})
+	}
+	return missing
+}
@@ -0,0 +1,116 @@
+package store
+
+import (
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/karalef/coincap"
+)
+
+func TestMissing(t *testing.T) {
+	covered := []Range{{10, 20}, {30, 40}, {15, 25}}
+	got := Missing(covered, Range{0, 50})
+	want := []Range{{0, 10}, {25, 30}, {40, 50}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Missing = %v, want %v", got, want)
+	}
+	if got := Missing(covered, Range{12, 24}); len(got) != 0 {
+		t.Errorf("expected nothing missing, got %v", got)
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	st, err := OpenFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := CandleSeries("binance", "ethereum", "bitcoin", coincap.Hour)
+
+	if err := st.PutCandles(s, Range{0, 3}, []coincap.Candle{{Period: 0, Close: 1}, {Period: 2, Close: 3}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.PutCandles(s, Range{2, 5}, []coincap.Candle{{Period: 2, Close: 4}, {Period: 4, Close: 5}}); err != nil {
+		t.Fatal(err)
+	}
+
+	cs, err := st.Candles(s, Range{1, 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cs) != 2 || cs[0].Close != 4 || cs[1].Period != 4 {
+		t.Errorf("unexpected candles %+v", cs)
+	}
+	cov, err := st.Covered(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cov, []Range{{0, 5}}) {
+		t.Errorf("unexpected coverage %v", cov)
+	}
+
+	if err := st.PutHistory(s, Range{}, nil); err != ErrKind {
+		t.Errorf("expected ErrKind, got %v", err)
+	}
+	if cov, _ := st.Covered(HistorySeries("bitcoin", coincap.Day)); len(cov) != 0 {
+		t.Errorf("unexpected coverage %v", cov)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestSyncWindows(t *testing.T) {
+	var spans []Range
+	c := coincap.NewClient(&http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		q := r.URL.Query()
+		start, _ := strconv.ParseInt(q.Get("start"), 10, 64)
+		end, _ := strconv.ParseInt(q.Get("end"), 10, 64)
+		spans = append(spans, Range{coincap.Timestamp(start), coincap.Timestamp(end)})
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{"data":[],"timestamp":1}`)),
+			Request:    r,
+		}, nil
+	})}, nil)
+	st, err := OpenFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := HistorySeries("bitcoin", coincap.Minute)
+	const min = coincap.Timestamp(time.Minute / time.Millisecond)
+	base := coincap.TimestampFromTime(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	syncer := &Syncer{Client: &c, Store: st, Points: 5000}
+	if err := syncer.Sync(s, Range{base, base + 4500*min}); err != nil {
+		t.Fatal(err)
+	}
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 requests, got %v", spans)
+	}
+	for _, sp := range spans {
+		if sp.End-sp.Start > 2000*min {
+			t.Errorf("request span %v exceeds the API limit", sp)
+		}
+	}
+
+	// a missing window shorter than the interval is still fetched
+	spans = nil
+	if err := syncer.Sync(s, Range{base, base + 4500*min + min/2}); err != nil {
+		t.Fatal(err)
+	}
+	if len(spans) != 1 || spans[0].End-spans[0].Start != min {
+		t.Fatalf("unexpected requests %v", spans)
+	}
+	cov, _ := st.Covered(s)
+	if !reflect.DeepEqual(cov, []Range{{base, base + 4500*min + min/2}}) {
+		t.Errorf("unexpected coverage %v", cov)
+	}
+}
@@ -0,0 +1,107 @@
+package store
+
+import (
+	"time"
+
+	"github.com/karalef/coincap"
+)
+
+// maxPoints is the maximum number of points returned by the API.
+const maxPoints = 2000
+
+// Syncer fetches missing series windows from the API into the store.
+type Syncer struct {
+	Client *coincap.Client
+	Store  Store
+	Points int // maximum number of points per request (1000 if zero, at most 2000)
+}
+
+// Sync fetches the parts of the range that are not stored yet.
+// The range end is limited to the start of the current interval period
+// so incomplete periods are never marked as covered.
+func (s *Syncer) Sync(series Series, r Range) error {
+	valid := series.Interval.ValidForCandles()
+	if series.Kind == History {
+		valid = series.Interval.ValidForHistory()
+	}
+	if !valid {
+		return coincap.ErrInvalidInterval
+	}
+	ms := coincap.Timestamp(series.Interval.Duration().Milliseconds())
+	if now := coincap.TimestampFromTime(time.Now()).Truncate(series.Interval); r.End > now {
+		r.End = now
+	}
+	if r.Empty() {
+		return nil
+	}
+
+	covered, err := s.Store.Covered(series)
+	if err != nil {
+		return err
+	}
+	points := s.Points
+	if points <= 0 {
+		points = 1000
+	} else if points > maxPoints {
+		points = maxPoints
+	}
+	chunk := ms * coincap.Timestamp(points)
+
+	for _, m := range Missing(covered, r) {
+		for start := m.Start; start < m.End; start += chunk {
+			w := Range{start, start + chunk}
+			if w.End > m.End {
+				w.End = m.End
+			}
+			if w.End-w.Start < ms {
+				// the API rejects spans shorter than the interval, so the
+				// window is extended back over already stored data
+				w.Start = w.End - ms
+			}
+			if err := s.fetch(series, w); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Syncer) fetch(series Series, w Range) error {
+	iv := &coincap.IntervalParams{
+		Interval: series.Interval,
+		Start:    w.Start.Time(),
+		End:      w.End.Time(),
+	}
+	if series.Kind == History {
+		h, _, err := s.Client.AssetHistory(series.Asset, iv)
+		if err != nil {
+			return err
+		}
+		return s.Store.PutHistory(series, w, h)
+	}
+	c, _, err := s.Client.Candles(coincap.CandlesRequest{
+		ExchangeID: series.Exchange,
+		BaseID:     series.Base,
+		QuoteID:    series.Quote,
+	}, iv, &coincap.TrimParams{Limit: maxPoints})
+	if err != nil {
+		return err
+	}
+	return s.Store.PutCandles(series, w, c)
+}
+
+// Candles syncs the range and returns the stored candles.
+func (s *Syncer) Candles(series Series, r Range) ([]coincap.Candle, error) {
+	if err := s.Sync(series, r); err != nil {
+		return nil, err
+	}
+	return s.Store.Candles(series, r)
+}
+
+// History syncs the range and returns the stored price history.
+func (s *Syncer) History(series Series, r Range) ([]coincap.AssetHistory, error) {
+	if err := s.Sync(series, r); err != nil {
+		return nil, err
+	}
+	return s.Store.History(series, r)
+}
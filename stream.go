@@ -2,25 +2,17 @@ package coincap
 
 import (
 	"errors"
+	"io"
+	"log/slog"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"unsafe"
 
 	"github.com/gorilla/websocket"
 )
 
-// Trade type.
-type Trade struct {
-	Exchange  string  `json:"exchange"`
-	Base      string  `json:"base"`
-	Quote     string  `json:"quote"`
-	Direction string  `json:"direction"`
-	Price     float64 `json:"price"`
-	Volume    float64 `json:"volume"`
-	Timestamp int64   `json:"timestamp"`
-	PriceUSD  float64 `json:"priceUds"`
-}
-
 // Trades streams trades from other cryptocurrency exchange websockets.
 // Users must select a specific exchange. In the /exchanges endpoint users
 // can determine if an exchange has a socket available by noting
@@ -28,6 +20,12 @@ type Trade struct {
 // The trades websocket is the only way to receive individual
 // trade data through CoinCap.
 func (c *Client) Trades(exchange string) (*Stream[*Trade], error) {
+	return c.TradesFilter(exchange, nil)
+}
+
+// TradesFilter is like Trades but delivers only the trades matching the filter.
+// The filter is applied inside the stream before delivery.
+func (c *Client) TradesFilter(exchange string, filter *TradeFilter) (*Stream[*Trade], error) {
 	e, _, err := c.ExchangeByID(exchange)
 	if err != nil {
 		return nil, err
@@ -39,7 +37,31 @@ func (c *Client) Trades(exchange string) (*Stream[*Trade], error) {
 		return nil, errors.New("exchange '" + exchange + "' does not support websockets")
 	}
 	const u = "wss://ws.coincap.io/trades/"
-	return dial[*Trade](c.ws, u+exchange)
+	return dial(c, "trades", u+exchange, filter.matcher(exchange))
+}
+
+// TradesMulti subscribes to trades of several exchanges and merges them
+// into one stream. Each trade is tagged with its exchange.
+// The filter may be nil.
+func (c *Client) TradesMulti(filter *TradeFilter, exchanges ...string) (*Stream[*Trade], error) {
+	if len(exchanges) == 0 {
+		return nil, errors.New("no exchanges")
+	}
+	streams := make([]*Stream[*Trade], 0, len(exchanges))
+	for _, e := range exchanges {
+		s, err := c.TradesFilter(e, filter)
+		if err != nil {
+			for _, s := range streams {
+				s.Close()
+			}
+			return nil, err
+		}
+		streams = append(streams, s)
+	}
+	if len(streams) == 1 {
+		return streams[0], nil
+	}
+	return mergeStreams(streams), nil
 }
 
 type price float64
@@ -62,17 +84,17 @@ func (p *price) UnmarshalJSON(data []byte) error {
 func (c *Client) Prices(assets ...string) (*Stream[map[string]float64], error) {
 	a := "ALL"
 	if len(assets) > 0 {
-		t, _, err := c.AssetsSearchByIDs(assets)
+		res, err := c.AssetsByIDs(assets, nil)
 		if err != nil {
 			return nil, err
 		}
-		if len(t) != len(assets) {
-			return nil, errors.New("incorrect assets ids")
+		if len(res.NotFound) > 0 {
+			return nil, errors.New("incorrect assets ids: " + strings.Join(res.NotFound, ","))
 		}
 		a = strings.Join(assets, ",")
 	}
 	const u = "wss://ws.coincap.io/prices?assets="
-	s, err := dial[map[string]price](c.ws, u+a)
+	s, err := dial[map[string]price](c, "prices", u+a, nil)
 	return (*Stream[map[string]float64])(unsafe.Pointer(s)), err
 }
 
@@ -81,7 +103,13 @@ type Stream[T any] struct {
 	ch   chan T
 	stop chan struct{}
 	conf chan struct{}
+	once sync.Once
 	err  error
+
+	hooks  Hooks
+	log    *slog.Logger
+	info   StreamInfo
+	filter func(T) bool
 }
 
 // DataChannel returns data channel.
@@ -91,61 +119,201 @@ func (s *Stream[T]) DataChannel() <-chan T {
 }
 
 // Close closes stream.
+// It is safe to call Close several times and from several goroutines.
 func (s *Stream[T]) Close() {
-	select {
-	case <-s.stop:
-	default:
-		close(s.stop)
-	}
+	s.once.Do(func() { close(s.stop) })
 	<-s.conf
 }
 
+// Err returns the error that caused the stream to stop.
 func (s *Stream[T]) Err() error {
 	return s.err
 }
 
+// send delivers the value to the data channel.
+// It returns false if the stream is closed.
+func (s *Stream[T]) send(v T) bool {
+	select {
+	case <-s.stop:
+		return false
+	case s.ch <- v:
+		return true
+	}
+}
+
+// done finishes the stream with the error.
+// The error is discarded if the stream was closed by the user.
+func (s *Stream[T]) done(err error) {
+	select {
+	case <-s.stop:
+		err = nil
+	default:
+	}
+	s.err = err
+	close(s.ch)
+	close(s.conf)
+}
+
 func (s *Stream[T]) dial(conn *websocket.Conn) {
-	defer func() {
+	// closing the connection interrupts the blocked NextReader
+	go func() {
 		select {
 		case <-s.stop:
-			s.err = nil
+			conn.Close()
+		case <-s.conf:
 		}
+	}()
+
+	var err error
+	defer func() {
 		conn.Close()
-		close(s.ch)
-		close(s.conf)
+		select {
+		case <-s.stop:
+			err = nil // closed by the user
+		default:
+		}
+		if s.hooks != nil {
+			s.hooks.StreamDisconnect(s.info, err)
+		}
+		logStreamDisconnect(s.log, s.info, err)
+		s.done(err)
 	}()
 	for {
-		_, r, err := conn.NextReader()
+		var r io.Reader
+		_, r, err = conn.NextReader()
 		if err != nil {
-			s.err = err
 			return
 		}
-		v, err := decodeJSON[T](r)
+		cr := &countingReader{r: r}
+		var v *T
+		v, err = decodeJSON[T](cr)
+		if s.hooks != nil {
+			s.hooks.StreamMessage(s.info, cr.n)
+		}
 		if err != nil {
-			s.err = err
 			return
 		}
-
-		select {
-		case <-s.stop:
+		if s.filter != nil && !s.filter(*v) {
+			select {
+			case <-s.stop:
+				return
+			default:
+			}
+			continue
+		}
+		if !s.send(*v) {
 			return
-		case s.ch <- *v:
 		}
 	}
 }
 
-func dial[T any](ws *websocket.Dialer, u string) (*Stream[T], error) {
-	conn, _, err := ws.Dial(u, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	s := Stream[T]{
+func newStream[T any]() *Stream[T] {
+	return &Stream[T]{
 		ch:   make(chan T),
 		stop: make(chan struct{}),
 		conf: make(chan struct{}),
 	}
+}
+
+var streamID atomic.Uint64
+
+func dial[T any](c *Client, name, u string, filter func(T) bool) (*Stream[T], error) {
+	info := StreamInfo{ID: streamID.Add(1), Name: name, URL: u}
+	conn, _, err := c.ws.Dial(u, nil)
+	if c.hooks != nil {
+		c.hooks.StreamConnect(info, err)
+	}
+	logStreamConnect(c.log, info, err)
+	if err != nil {
+		return nil, err
+	}
+
+	s := newStream[T]()
+	s.hooks, s.log, s.info, s.filter = c.hooks, c.log, info, filter
 	go s.dial(conn)
 
-	return &s, nil
+	return s, nil
+}
+
+// mergeStreams merges several streams into one.
+// The merged stream stops when any of the streams stops.
+func mergeStreams[T any](streams []*Stream[T]) *Stream[T] {
+	s := newStream[T]()
+	var (
+		once sync.Once
+		err  error
+		wg   sync.WaitGroup
+	)
+	stopAll := func(e error) {
+		once.Do(func() {
+			err = e
+			for _, st := range streams {
+				go st.Close()
+			}
+		})
+	}
+	wg.Add(len(streams))
+	for _, st := range streams {
+		go func(st *Stream[T]) {
+			defer wg.Done()
+			for v := range st.DataChannel() {
+				if !s.send(v) {
+					stopAll(nil)
+					return
+				}
+			}
+			stopAll(st.Err())
+		}(st)
+	}
+	go func() {
+		select {
+		case <-s.stop:
+			stopAll(nil)
+		case <-s.conf:
+		}
+	}()
+	go func() {
+		wg.Wait()
+		for _, st := range streams {
+			st.Close()
+		}
+		s.done(err)
+	}()
+	return s
+}
+
+// pipe creates a stream of values produced by fn from each value of the
+// source stream. fn must return false if send returned false.
+// The source stream is closed when the resulting stream stops.
+func pipe[S, T any](src *Stream[S], fn func(v S, send func(T) bool) bool) *Stream[T] {
+	return pipeErr(src, fn, nil)
+}
+
+// pipeErr is like pipe but the stream stops with the error returned
+// by errf if it is not nil.
+func pipeErr[S, T any](src *Stream[S], fn func(v S, send func(T) bool) bool, errf func() error) *Stream[T] {
+	s := newStream[T]()
+	go func() {
+		for v := range src.DataChannel() {
+			if !fn(v, s.send) {
+				break
+			}
+		}
+		src.Close()
+		err := src.Err()
+		if errf != nil {
+			if e := errf(); e != nil {
+				err = e
+			}
+		}
+		s.done(err)
+	}()
+	go func() {
+		select {
+		case <-s.stop:
+			src.Close()
+		case <-s.conf:
+		}
+	}()
+	return s
 }
@@ -1,7 +1,14 @@
 package coincap
 
 import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
 )
 
 func TestTradesInvalidExchange(t *testing.T) {
@@ -96,3 +103,174 @@ func TestPrices(t *testing.T) {
 		}
 	}
 }
+
+func TestTradeFilter(t *testing.T) {
+	f := &TradeFilter{Bases: []string{"bitcoin"}, Direction: Buy, MinNotionalUSD: 1000}
+	tests := []struct {
+		trade Trade
+		match bool
+	}{
+		{Trade{Base: "bitcoin", Quote: "tether", Direction: Buy, Volume: 0.1, PriceUSD: 20000}, true},
+		{Trade{Base: "bitcoin", Quote: "tether", Direction: Sell, Volume: 0.1, PriceUSD: 20000}, false},
+		{Trade{Base: "bitcoin", Quote: "tether", Direction: Buy, Volume: 0.01, PriceUSD: 20000}, false},
+		{Trade{Base: "ethereum", Quote: "tether", Direction: Buy, Volume: 10, PriceUSD: 1500}, false},
+	}
+	for i, tt := range tests {
+		if got := f.Match(&tt.trade); got != tt.match {
+			t.Errorf("%d: Match = %v, want %v", i, got, tt.match)
+		}
+	}
+}
+
+// testSource returns a stream sending the values and stopping with the error.
+func testSource[T any](err error, values ...T) *Stream[T] {
+	s := newStream[T]()
+	go func() {
+		for _, v := range values {
+			if !s.send(v) {
+				s.done(nil)
+				return
+			}
+		}
+		s.done(err)
+	}()
+	return s
+}
+
+func TestMergeStreams(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		fail := errors.New("source failed")
+		idle := newStream[int]() // never sends, must be closed by the merge
+		go func() {
+			<-idle.stop
+			idle.done(nil)
+		}()
+		s := mergeStreams([]*Stream[int]{testSource(fail, 1, 2, 3), idle})
+
+		n := 0
+		for range s.DataChannel() {
+			n++
+		}
+		if n != 3 || !errors.Is(s.Err(), fail) {
+			t.Fatalf("got %d values and error %v", n, s.Err())
+		}
+		s.Close()
+	}
+}
+
+func TestMergeStreamsClose(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		a, b := testSource(nil, 1, 2, 3), testSource(nil, 4, 5, 6)
+		s := mergeStreams([]*Stream[int]{a, b})
+		<-s.DataChannel()
+		s.Close()
+		s.Close()
+		if s.Err() != nil {
+			t.Fatal(s.Err())
+		}
+		for _, src := range []*Stream[int]{a, b} {
+			if _, ok := <-src.DataChannel(); ok {
+				t.Fatal("source stream is not closed")
+			}
+		}
+	}
+}
+
+func TestPipeClose(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		src := testSource(nil, 1, 2, 3, 4, 5)
+		s := pipe(src, func(v int, send func(int) bool) bool {
+			return send(v * 2)
+		})
+		if v := <-s.DataChannel(); v != 2 {
+			t.Fatalf("unexpected value %d", v)
+		}
+		s.Close() // the pipe is blocked in send
+		if s.Err() != nil {
+			t.Fatal(s.Err())
+		}
+		if _, ok := <-src.DataChannel(); ok {
+			t.Fatal("source stream is not closed")
+		}
+	}
+}
+
+func TestFilteredStreamClose(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			err := conn.WriteMessage(websocket.TextMessage, []byte(`{"base":"bitcoin","quote":"tether"}`))
+			if err != nil {
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(nil, nil)
+	filter := &TradeFilter{Bases: []string{"ethereum"}}
+	s, err := dial(&c, "trades", "ws"+strings.TrimPrefix(srv.URL, "http"), filter.matcher("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		s.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Close blocked on a stream whose filter rejects everything")
+	}
+	if s.Err() != nil {
+		t.Error(s.Err())
+	}
+}
+
+func TestStreamDisconnectHook(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"base":`))
+		conn.ReadMessage()
+	}))
+	defer srv.Close()
+
+	var disconnects []error
+	c := NewClient(nil, nil).WithHooks(HookFuncs{
+		OnStreamDisconnect: func(s StreamInfo, err error) {
+			disconnects = append(disconnects, err)
+		},
+	})
+	s, err := dial[*Trade](&c, "trades", "ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range s.DataChannel() {
+	}
+	if s.Err() == nil {
+		t.Fatal("expected a decoding error")
+	}
+	if len(disconnects) != 1 || disconnects[0] != s.Err() {
+		t.Errorf("unexpected disconnects %v", disconnects)
+	}
+}
+
+func TestTradesMultiNoExchanges(t *testing.T) {
+	if _, err := DefaultClient.TradesMulti(nil); err == nil {
+		t.Error("expected error")
+	}
+}
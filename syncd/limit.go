@@ -0,0 +1,72 @@
+package syncd
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// limiter is a token bucket.
+type limiter struct {
+	mu     sync.Mutex
+	tokens float64
+	max    float64
+	rate   float64 // tokens per second
+	last   time.Time
+}
+
+func newLimiter(n int, per time.Duration) *limiter {
+	return &limiter{
+		tokens: float64(n),
+		max:    float64(n),
+		rate:   float64(n) / per.Seconds(),
+		last:   time.Now(),
+	}
+}
+
+// reserve takes a token and returns the time to wait before using it.
+func (l *limiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.max {
+		l.tokens = l.max
+	}
+	l.last = now
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// errStopped is returned by requests waiting for the budget after Close.
+var errStopped = errors.New("syncd: stopped")
+
+// limitedTransport delays requests to stay within the budget.
+type limitedTransport struct {
+	base    http.RoundTripper
+	limiter *limiter
+	stop    <-chan struct{}
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if wait := t.limiter.reserve(); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-t.stop:
+			return nil, errStopped
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req)
+}
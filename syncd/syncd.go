@@ -0,0 +1,301 @@
+// Package syncd implements a service that periodically mirrors
+// CoinCap data into a local store.
+package syncd
+
+import (
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/karalef/coincap"
+	"github.com/karalef/coincap/store"
+)
+
+// Config contains sync parameters.
+// Zero job intervals disable the corresponding jobs.
+type Config struct {
+	Dir string // store directory
+
+	Assets     time.Duration // assets polling interval
+	AssetLimit uint          // maximum number of polled assets (API default if zero)
+	Exchanges  time.Duration // exchanges polling interval
+	Rates      time.Duration // rates polling interval
+	Markets    time.Duration // markets polling interval
+
+	Candles         []store.Series // candle series to sync
+	CandlesEvery    time.Duration  // candles sync interval (1 hour if zero)
+	CandlesLookback time.Duration  // synced candles history (30 days if zero)
+
+	RequestsPerMinute int          // request budget shared by all jobs (100 if zero)
+	HTTPClient        *http.Client // base HTTP client (http.DefaultClient if nil)
+	Logger            *slog.Logger // logger (no logging if nil)
+
+	OnChange func(Change) // called after a job stores a changed snapshot
+}
+
+// Change summarizes the difference between two snapshots as reported by
+// the coincap Diff functions, so Updated fields alone are not a change.
+type Change struct {
+	Job     string
+	Time    coincap.Timestamp
+	Added   int
+	Removed int
+	Changed int // items with changed fields or rank
+}
+
+// checkpointName is the snapshot name of the jobs checkpoints.
+const checkpointName = "checkpoints"
+
+// Daemon runs the sync jobs.
+type Daemon struct {
+	cfg    Config
+	client coincap.Client
+	store  *store.FileStore
+	syncer *store.Syncer
+	log    *slog.Logger
+
+	mu          sync.Mutex
+	checkpoints map[string]coincap.Timestamp // last successful run by job
+	err         error
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// New opens the store and creates a new daemon.
+func New(cfg Config) (*Daemon, error) {
+	if cfg.CandlesEvery <= 0 {
+		cfg.CandlesEvery = time.Hour
+	}
+	if cfg.CandlesLookback <= 0 {
+		cfg.CandlesLookback = 30 * 24 * time.Hour
+	}
+	if cfg.RequestsPerMinute <= 0 {
+		cfg.RequestsPerMinute = 100
+	}
+	base := cfg.HTTPClient
+	if base == nil {
+		base = http.DefaultClient
+	}
+
+	st, err := store.OpenFileStore(cfg.Dir)
+	if err != nil {
+		return nil, err
+	}
+	d := &Daemon{
+		cfg:         cfg,
+		store:       st,
+		log:         cfg.Logger,
+		checkpoints: make(map[string]coincap.Timestamp),
+		stop:        make(chan struct{}),
+	}
+	if _, err = st.Snapshot(checkpointName, &d.checkpoints); err != nil {
+		return nil, err
+	}
+
+	hc := *base
+	hc.Transport = &limitedTransport{
+		base:    base.Transport,
+		limiter: newLimiter(cfg.RequestsPerMinute, time.Minute),
+		stop:    d.stop,
+	}
+	d.client = coincap.NewClient(&hc, nil)
+	if d.log != nil {
+		d.client = d.client.WithLogger(d.log)
+	}
+	d.syncer = &store.Syncer{Client: &d.client, Store: st}
+	return d, nil
+}
+
+// Store returns the store the daemon writes to.
+func (d *Daemon) Store() *store.FileStore {
+	return d.store
+}
+
+type job struct {
+	name     string
+	interval time.Duration
+	run      func() (*Change, error)
+}
+
+func (d *Daemon) jobs() []job {
+	jobs := []job{
+		{"assets", d.cfg.Assets, d.syncAssets},
+		{"exchanges", d.cfg.Exchanges, d.syncExchanges},
+		{"rates", d.cfg.Rates, d.syncRates},
+		{"markets", d.cfg.Markets, d.syncMarkets},
+	}
+	if len(d.cfg.Candles) > 0 {
+		jobs = append(jobs, job{"candles", d.cfg.CandlesEvery, d.syncCandles})
+	}
+	return jobs
+}
+
+// Start starts the jobs in the background. Each job first runs when its
+// interval has passed since the last successful run recorded in the store.
+func (d *Daemon) Start() {
+	for _, j := range d.jobs() {
+		if j.interval <= 0 {
+			continue
+		}
+		d.wg.Add(1)
+		go d.loop(j)
+	}
+}
+
+// Close stops the jobs and waits for them to finish.
+func (d *Daemon) Close() {
+	select {
+	case <-d.stop:
+	default:
+		close(d.stop)
+	}
+	d.wg.Wait()
+}
+
+// Err returns the last job error.
+func (d *Daemon) Err() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.err
+}
+
+func (d *Daemon) loop(j job) {
+	defer d.wg.Done()
+
+	d.mu.Lock()
+	last := d.checkpoints[j.name]
+	d.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(last.Time().Add(j.interval)))
+	defer timer.Stop()
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-timer.C:
+		}
+		d.runJob(j)
+		timer.Reset(j.interval)
+	}
+}
+
+func (d *Daemon) runJob(j job) {
+	start := time.Now()
+	ch, err := j.run()
+	if err != nil {
+		select {
+		case <-d.stop:
+			return // interrupted by Close
+		default:
+		}
+		d.mu.Lock()
+		d.err = err
+		d.mu.Unlock()
+		if d.log != nil {
+			d.log.Error("coincap sync failed", "job", j.name, "error", err)
+		}
+		return
+	}
+
+	// the checkpoints are written under the lock so concurrent jobs
+	// cannot overwrite a newer state with an older one
+	d.mu.Lock()
+	d.checkpoints[j.name] = coincap.TimestampFromTime(start)
+	if err = d.store.PutSnapshot(checkpointName, coincap.TimestampFromTime(start), d.checkpoints); err != nil {
+		d.err = err
+	}
+	d.mu.Unlock()
+
+	if d.log != nil {
+		d.log.Debug("coincap sync", "job", j.name, "duration", time.Since(start))
+	}
+	if ch != nil && d.cfg.OnChange != nil {
+		d.cfg.OnChange(*ch)
+	}
+}
+
+// snapshot compares the fetched list with the stored one and stores
+// it if it has changed. It returns nil if nothing has changed.
+func snapshot[T any](d *Daemon, name string, ts coincap.Timestamp, list []T,
+	diff func(prev, cur []T, opts *coincap.DiffOptions) []coincap.Change[T]) (*Change, error) {
+	var old []T
+	prev, err := d.store.Snapshot(name, &old)
+	if err != nil {
+		return nil, err
+	}
+	ch := countChanges(diff(old, list, nil))
+	if prev != 0 && ch.Added+ch.Removed+ch.Changed == 0 {
+		return nil, nil
+	}
+	ch.Job, ch.Time = name, ts
+	return &ch, d.store.PutSnapshot(name, ts, list)
+}
+
+// countChanges counts the added, removed and changed items.
+func countChanges[T any](changes []coincap.Change[T]) Change {
+	var ch Change
+	changed := make(map[string]struct{})
+	for _, c := range changes {
+		switch c.Kind {
+		case coincap.ChangeAdded:
+			ch.Added++
+		case coincap.ChangeRemoved:
+			ch.Removed++
+		default:
+			changed[c.Key] = struct{}{}
+		}
+	}
+	ch.Changed = len(changed)
+	return ch
+}
+
+func (d *Daemon) syncAssets() (*Change, error) {
+	assets, ts, err := d.client.AssetsSearch("", &coincap.TrimParams{Limit: d.cfg.AssetLimit})
+	if err != nil {
+		return nil, err
+	}
+	return snapshot(d, "assets", ts, assets, coincap.DiffAssets)
+}
+
+func (d *Daemon) syncExchanges() (*Change, error) {
+	exchanges, ts, err := d.client.Exchanges()
+	if err != nil {
+		return nil, err
+	}
+	return snapshot(d, "exchanges", ts, exchanges, coincap.DiffExchanges)
+}
+
+func (d *Daemon) syncRates() (*Change, error) {
+	rates, ts, err := d.client.Rates()
+	if err != nil {
+		return nil, err
+	}
+	return snapshot(d, "rates", ts, rates, coincap.DiffRates)
+}
+
+func (d *Daemon) syncMarkets() (*Change, error) {
+	markets, err := d.client.AllMarkets(coincap.MarketsRequest{})
+	if err != nil {
+		return nil, err
+	}
+	ts := coincap.TimestampFromTime(time.Now())
+	return snapshot(d, "markets", ts, markets, coincap.DiffMarkets)
+}
+
+func (d *Daemon) syncCandles() (*Change, error) {
+	now := time.Now()
+	r := store.TimeRange(now.Add(-d.cfg.CandlesLookback), now)
+	for _, s := range d.cfg.Candles {
+		select {
+		case <-d.stop:
+			return nil, errStopped
+		default:
+		}
+		if err := d.syncer.Sync(s, r); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
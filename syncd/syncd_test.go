@@ -0,0 +1,101 @@
+package syncd
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/karalef/coincap"
+	"github.com/karalef/coincap/store"
+)
+
+func TestCountChanges(t *testing.T) {
+	old := []coincap.Rate{{ID: "bitcoin", RateUSD: 1}, {ID: "euro", RateUSD: 2}, {ID: "yen", RateUSD: 3}}
+	list := []coincap.Rate{{ID: "bitcoin", RateUSD: 1}, {ID: "euro", Symbol: "EUR", RateUSD: 2.5}, {ID: "pound", RateUSD: 4}}
+	ch := countChanges(coincap.DiffRates(old, list, nil))
+	if ch.Added != 1 || ch.Removed != 1 || ch.Changed != 1 {
+		t.Errorf("unexpected change %+v", ch)
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	l := newLimiter(2, time.Second)
+	if l.reserve() != 0 || l.reserve() != 0 {
+		t.Fatal("expected the budget to be available")
+	}
+	if w := l.reserve(); w <= 0 || w > 500*time.Millisecond {
+		t.Errorf("unexpected wait %v", w)
+	}
+}
+
+func TestCheckpoints(t *testing.T) {
+	dir := t.TempDir()
+	d, err := New(Config{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := 0
+	d.runJob(job{name: "rates", run: func() (*Change, error) {
+		calls++
+		return snapshot(d, "rates", 1, []coincap.Rate{{ID: "euro"}}, coincap.DiffRates)
+	}})
+	if calls != 1 || d.Err() != nil {
+		t.Fatal(d.Err())
+	}
+
+	d, err = New(Config{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.checkpoints["rates"] == 0 {
+		t.Error("checkpoint is not restored")
+	}
+	ch, err := snapshot(d, "rates", 2, []coincap.Rate{{ID: "euro"}}, coincap.DiffRates)
+	if err != nil || ch != nil {
+		t.Errorf("expected no change, got %+v, %v", ch, err)
+	}
+}
+
+func TestInterruptedCandles(t *testing.T) {
+	d, err := New(Config{Dir: t.TempDir(), Candles: []store.Series{
+		store.CandleSeries("binance", "ethereum", "bitcoin", coincap.Hour),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Close()
+	d.runJob(job{name: "candles", run: d.syncCandles})
+	if d.checkpoints["candles"] != 0 {
+		t.Error("interrupted run must not be checkpointed")
+	}
+}
+
+func TestConcurrentCheckpoints(t *testing.T) {
+	dir := t.TempDir()
+	d, err := New(Config{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{"assets", "exchanges", "rates", "markets"}
+	var wg sync.WaitGroup
+	for _, name := range names {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				d.runJob(job{name: name, run: func() (*Change, error) { return nil, nil }})
+			}
+		}(name)
+	}
+	wg.Wait()
+
+	var cp map[string]coincap.Timestamp
+	if _, err := d.Store().Snapshot(checkpointName, &cp); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		if cp[name] == 0 {
+			t.Errorf("checkpoint of %s is lost", name)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package coincap
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// TapeOptions contains trade tape analytics parameters.
+type TapeOptions struct {
+	Window       time.Duration // rolling time window (1 minute if zero)
+	VolumeWindow float64       // rolling base volume window for VWAP (disabled if zero)
+	LargeTrade   float64       // minimum USD notional of a large trade (disabled if zero)
+	BucketSize   float64       // price bucket size of the volume profile (disabled if zero)
+}
+
+// ProfileLevel is the traded volume at a price bucket.
+type ProfileLevel struct {
+	Price      float64 // lower bound of the bucket
+	BuyVolume  float64
+	SellVolume float64
+}
+
+// TapeSnapshot contains the rolling analytics of a pair.
+type TapeSnapshot struct {
+	Exchange        string
+	Base            string
+	Quote           string
+	Time            Timestamp      // time of the last trade
+	LastPrice       float64        // price of the last trade
+	Trades          int            // number of trades in the time window
+	TradesPerSecond float64        // trades per second in the time window
+	VWAP            float64        // volume weighted average price in the time window
+	VolumeVWAP      float64        // volume weighted average price over the volume window
+	BuyVolume       float64        // base volume bought in the time window
+	SellVolume      float64        // base volume sold in the time window
+	Imbalance       float64        // (buy - sell) / (buy + sell) in the time window
+	LargeTrades     []Trade        // large trades in the time window
+	Profile         []ProfileLevel // volume profile since the start ordered by price
+}
+
+type tapeKey struct {
+	exchange, base, quote string
+}
+
+type tapePair struct {
+	trades  []Trade // in the time window
+	volume  []Trade // in the volume window
+	volSum  float64
+	profile map[float64]*ProfileLevel
+}
+
+// Tape computes rolling analytics of trades per pair.
+//
+// It is safe for concurrent use.
+type Tape struct {
+	opts TapeOptions
+
+	mu    sync.Mutex
+	pairs map[tapeKey]*tapePair
+}
+
+// NewTape creates a new trade tape.
+func NewTape(opts *TapeOptions) *Tape {
+	t := &Tape{pairs: make(map[tapeKey]*tapePair)}
+	if opts != nil {
+		t.opts = *opts
+	}
+	if t.opts.Window <= 0 {
+		t.opts.Window = time.Minute
+	}
+	return t
+}
+
+// Add adds the trade to the tape and reports whether it is a large trade.
+func (t *Tape) Add(tr *Trade) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	k := tapeKey{tr.Exchange, tr.Base, tr.Quote}
+	p, ok := t.pairs[k]
+	if !ok {
+		p = &tapePair{profile: make(map[float64]*ProfileLevel)}
+		t.pairs[k] = p
+	}
+
+	p.trades = append(p.trades, *tr)
+	cut := 0
+	window := t.opts.Window.Milliseconds()
+	for cut < len(p.trades)-1 && int64(tr.Timestamp-p.trades[cut].Timestamp) > window {
+		cut++
+	}
+	p.trades = p.trades[cut:]
+
+	if t.opts.VolumeWindow > 0 {
+		p.volume = append(p.volume, *tr)
+		p.volSum += tr.Volume
+		for len(p.volume) > 1 && p.volSum-p.volume[0].Volume >= t.opts.VolumeWindow {
+			p.volSum -= p.volume[0].Volume
+			p.volume = p.volume[1:]
+		}
+	}
+
+	if t.opts.BucketSize > 0 {
+		b := math.Floor(tr.Price/t.opts.BucketSize) * t.opts.BucketSize
+		lvl, ok := p.profile[b]
+		if !ok {
+			lvl = &ProfileLevel{Price: b}
+			p.profile[b] = lvl
+		}
+		switch tr.Direction {
+		case Buy:
+			lvl.BuyVolume += tr.Volume
+		case Sell:
+			lvl.SellVolume += tr.Volume
+		}
+	}
+
+	return t.isLarge(tr)
+}
+
+func (t *Tape) isLarge(tr *Trade) bool {
+	return t.opts.LargeTrade > 0 && tr.NotionalUSD() >= t.opts.LargeTrade
+}
+
+func vwap(trades []Trade) float64 {
+	var pv, v float64
+	for i := range trades {
+		pv += trades[i].Price * trades[i].Volume
+		v += trades[i].Volume
+	}
+	if v == 0 {
+		return 0
+	}
+	return pv / v
+}
+
+func (t *Tape) snapshot(k tapeKey, p *tapePair) TapeSnapshot {
+	last := &p.trades[len(p.trades)-1]
+	s := TapeSnapshot{
+		Exchange:   k.exchange,
+		Base:       k.base,
+		Quote:      k.quote,
+		Time:       last.Timestamp,
+		LastPrice:  last.Price,
+		Trades:     len(p.trades),
+		VWAP:       vwap(p.trades),
+		VolumeVWAP: vwap(p.volume),
+	}
+
+	span := t.opts.Window
+	if first := p.trades[0].Timestamp; len(p.trades) > 1 {
+		if d := time.Duration(last.Timestamp-first) * time.Millisecond; d < span && d > 0 {
+			span = d
+		}
+	}
+	s.TradesPerSecond = float64(s.Trades) / span.Seconds()
+
+	for i := range p.trades {
+		tr := &p.trades[i]
+		switch tr.Direction {
+		case Buy:
+			s.BuyVolume += tr.Volume
+		case Sell:
+			s.SellVolume += tr.Volume
+		}
+		if t.isLarge(tr) {
+			s.LargeTrades = append(s.LargeTrades, *tr)
+		}
+	}
+	if total := s.BuyVolume + s.SellVolume; total > 0 {
+		s.Imbalance = (s.BuyVolume - s.SellVolume) / total
+	}
+
+	for _, lvl := range p.profile {
+		s.Profile = append(s.Profile, *lvl)
+	}
+	sort.Slice(s.Profile, func(i, j int) bool {
+		return s.Profile[i].Price < s.Profile[j].Price
+	})
+	return s
+}
+
+// Snapshot returns the analytics of the pair.
+func (t *Tape) Snapshot(exchange, base, quote string) (TapeSnapshot, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	k := tapeKey{exchange, base, quote}
+	p, ok := t.pairs[k]
+	if !ok {
+		return TapeSnapshot{}, false
+	}
+	return t.snapshot(k, p), true
+}
+
+// Snapshots returns the analytics of all pairs.
+func (t *Tape) Snapshots() []TapeSnapshot {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := make([]TapeSnapshot, 0, len(t.pairs))
+	for k, p := range t.pairs {
+		s = append(s, t.snapshot(k, p))
+	}
+	sort.Slice(s, func(i, j int) bool {
+		a, b := s[i], s[j]
+		if a.Exchange != b.Exchange {
+			return a.Exchange < b.Exchange
+		}
+		if a.Base != b.Base {
+			return a.Base < b.Base
+		}
+		return a.Quote < b.Quote
+	})
+	return s
+}
+
+// Watch consumes the trades stream and streams the snapshots of all pairs
+// every interval (1 second if not positive).
+// The trades stream is closed when the returned stream stops.
+func (t *Tape) Watch(trades *Stream[*Trade], interval time.Duration) *Stream[[]TapeSnapshot] {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	s := newStream[[]TapeSnapshot]()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		ch := trades.DataChannel()
+	loop:
+		for {
+			select {
+			case <-s.stop:
+				break loop
+			case tr, ok := <-ch:
+				if !ok {
+					break loop
+				}
+				t.Add(tr)
+			case <-ticker.C:
+				if !s.send(t.Snapshots()) {
+					break loop
+				}
+			}
+		}
+		trades.Close()
+		s.done(trades.Err())
+	}()
+	return s
+}
@@ -0,0 +1,47 @@
+package coincap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTape(t *testing.T) {
+	tape := NewTape(&TapeOptions{Window: 10 * time.Second, VolumeWindow: 3, LargeTrade: 50000, BucketSize: 100})
+	trades := []Trade{
+		{Price: 100, Volume: 1, Direction: Buy, Timestamp: 0, PriceUSD: 100},
+		{Price: 200, Volume: 1, Direction: Buy, Timestamp: 5000, PriceUSD: 200},
+		{Price: 300, Volume: 2, Direction: Sell, Timestamp: 12000, PriceUSD: 30000},
+		{Price: 250, Volume: 2, Direction: Buy, Timestamp: 14000, PriceUSD: 25000},
+	}
+	var large int
+	for i := range trades {
+		trades[i].Exchange, trades[i].Base, trades[i].Quote = "x", "bitcoin", "tether"
+		if tape.Add(&trades[i]) {
+			large++
+		}
+	}
+	if large != 2 {
+		t.Errorf("expected 2 large trades, got %d", large)
+	}
+
+	s, ok := tape.Snapshot("x", "bitcoin", "tether")
+	if !ok {
+		t.Fatal("no snapshot")
+	}
+	// the first trade is out of the time window.
+	if s.Trades != 3 || !almostEqual(s.VWAP, (200+600+500)/5.0) {
+		t.Errorf("unexpected window stats %+v", s)
+	}
+	if !almostEqual(s.VolumeVWAP, (600+500)/4.0) {
+		t.Errorf("unexpected volume VWAP %v", s.VolumeVWAP)
+	}
+	if !almostEqual(s.Imbalance, (3-2)/5.0) || len(s.LargeTrades) != 2 {
+		t.Errorf("unexpected flow stats %+v", s)
+	}
+	if !almostEqual(s.TradesPerSecond, 3/9.0) {
+		t.Errorf("unexpected trades per second %v", s.TradesPerSecond)
+	}
+	if len(s.Profile) != 3 || s.Profile[2].Price != 300 || s.Profile[2].SellVolume != 2 {
+		t.Errorf("unexpected profile %+v", s.Profile)
+	}
+}
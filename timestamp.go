@@ -0,0 +1,120 @@
+package coincap
+
+import (
+	"database/sql/driver"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// TimestampFromTime converts the time into CoinCap timestamp.
+func TimestampFromTime(t time.Time) Timestamp {
+	return Timestamp(t.UnixMilli())
+}
+
+// Add returns the timestamp t+d truncated to milliseconds.
+func (t Timestamp) Add(d time.Duration) Timestamp {
+	return t + Timestamp(d.Milliseconds())
+}
+
+// Sub returns the duration t-u.
+func (t Timestamp) Sub(u Timestamp) time.Duration {
+	return time.Duration(t-u) * time.Millisecond
+}
+
+// Truncate rounds the timestamp down to a multiple of the interval
+// duration since the Unix epoch. Invalid intervals leave it unchanged.
+func (t Timestamp) Truncate(i Interval) Timestamp {
+	ms := Timestamp(i.Duration().Milliseconds())
+	if ms <= 0 {
+		return t
+	}
+	r := t % ms
+	if r < 0 {
+		r += ms
+	}
+	return t - r
+}
+
+// ErrInvalidTimestamp is returned when a timestamp cannot be decoded.
+var ErrInvalidTimestamp = errors.New("invalid timestamp")
+
+func parseTimestamp(s string) (Timestamp, error) {
+	if v, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return Timestamp(v), nil
+	}
+	if v, err := strconv.ParseFloat(s, 64); err == nil {
+		return Timestamp(v), nil
+	}
+	return 0, &valueError{ErrInvalidTimestamp, s}
+}
+
+// MarshalJSON is json.Marshaler implementation.
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	return strconv.AppendInt(nil, int64(t), 10), nil
+}
+
+// UnmarshalJSON is json.Unmarshaler implementation.
+// It accepts numbers and numeric strings; null leaves the timestamp unchanged.
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		s = s[1 : len(s)-1]
+	}
+	v, err := parseTimestamp(s)
+	if err != nil {
+		return err
+	}
+	*t = v
+	return nil
+}
+
+// MarshalText is encoding.TextMarshaler implementation.
+func (t Timestamp) MarshalText() ([]byte, error) {
+	return strconv.AppendInt(nil, int64(t), 10), nil
+}
+
+// UnmarshalText is encoding.TextUnmarshaler implementation.
+// It accepts milliseconds or RFC 3339 time.
+func (t *Timestamp) UnmarshalText(text []byte) error {
+	v, err := parseTimestamp(string(text))
+	if err != nil {
+		tm, terr := time.Parse(time.RFC3339Nano, string(text))
+		if terr != nil {
+			return err
+		}
+		v = TimestampFromTime(tm)
+	}
+	*t = v
+	return nil
+}
+
+// Scan is sql.Scanner implementation.
+// It accepts integers, floats, numeric strings and times; NULL sets zero.
+func (t *Timestamp) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*t = 0
+	case int64:
+		*t = Timestamp(v)
+	case float64:
+		*t = Timestamp(v)
+	case time.Time:
+		*t = TimestampFromTime(v)
+	case []byte:
+		return t.UnmarshalText(v)
+	case string:
+		return t.UnmarshalText([]byte(v))
+	default:
+		return ErrInvalidTimestamp
+	}
+	return nil
+}
+
+// Value is driver.Valuer implementation.
+func (t Timestamp) Value() (driver.Value, error) {
+	return int64(t), nil
+}
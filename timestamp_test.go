@@ -0,0 +1,58 @@
+package coincap
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTimestampJSON(t *testing.T) {
+	var v struct {
+		A Timestamp `json:"a"`
+		B Timestamp `json:"b"`
+		C Timestamp `json:"c"`
+	}
+	v.C = 7
+	if err := json.Unmarshal([]byte(`{"a":1660000000000,"b":"1660000000001","c":null}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.A != 1660000000000 || v.B != 1660000000001 || v.C != 7 {
+		t.Errorf("unexpected values %+v", v)
+	}
+	if err := json.Unmarshal([]byte(`{"a":"x"}`), &v); !errors.Is(err, ErrInvalidTimestamp) {
+		t.Error("expected error")
+	}
+	b, _ := json.Marshal(v)
+	if string(b) != `{"a":1660000000000,"b":1660000000001,"c":7}` {
+		t.Errorf("unexpected json %s", b)
+	}
+}
+
+func TestTimestampSQL(t *testing.T) {
+	var ts Timestamp
+	tm := time.Date(2022, 8, 8, 0, 0, 0, 0, time.UTC)
+	for _, src := range []any{int64(1659916800000), float64(1659916800000), "1659916800000",
+		[]byte("2022-08-08T00:00:00Z"), tm} {
+		ts = 0
+		if err := ts.Scan(src); err != nil || ts != TimestampFromTime(tm) {
+			t.Errorf("Scan(%v) = %v, %v", src, ts, err)
+		}
+	}
+	if v, _ := ts.Value(); v != int64(1659916800000) {
+		t.Errorf("unexpected value %v", v)
+	}
+}
+
+func TestTimestampArithmetic(t *testing.T) {
+	ts := TimestampFromTime(time.Date(2022, 8, 8, 13, 47, 5, 0, time.UTC))
+	if got := ts.Truncate(FifteenMinutes).Time().UTC(); !got.Equal(time.Date(2022, 8, 8, 13, 45, 0, 0, time.UTC)) {
+		t.Errorf("Truncate(m15) = %v", got)
+	}
+	if got := ts.Truncate(Day).Time().UTC(); !got.Equal(time.Date(2022, 8, 8, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Truncate(d1) = %v", got)
+	}
+	if ts.Add(time.Hour).Sub(ts) != time.Hour {
+		t.Error("Add and Sub mismatch")
+	}
+}
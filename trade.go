@@ -0,0 +1,95 @@
+package coincap
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Side is a trade direction.
+type Side uint8
+
+// Trade sides.
+const (
+	UnknownSide Side = iota
+	Buy
+	Sell
+)
+
+func (s Side) String() string {
+	switch s {
+	case Buy:
+		return "buy"
+	case Sell:
+		return "sell"
+	}
+	return ""
+}
+
+// ParseSide parses the trade side case-insensitively.
+// Unknown values return UnknownSide and an error.
+func ParseSide(s string) (Side, error) {
+	switch strings.ToLower(s) {
+	case "buy":
+		return Buy, nil
+	case "sell":
+		return Sell, nil
+	case "":
+		return UnknownSide, nil
+	}
+	return UnknownSide, errors.New("invalid trade side '" + s + "'")
+}
+
+// MarshalText is encoding.TextMarshaler implementation.
+func (s Side) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText is encoding.TextUnmarshaler implementation.
+// Unknown values are decoded as UnknownSide without an error
+// so an unexpected direction does not stop the trades stream.
+func (s *Side) UnmarshalText(text []byte) error {
+	*s, _ = ParseSide(string(text))
+	return nil
+}
+
+// Trade contains a single trade from the exchange trades stream.
+type Trade struct {
+	Exchange  string    `json:"exchange"`  // unique identifier for exchange
+	Base      string    `json:"base"`      // unique identifier for the asset purchased
+	Quote     string    `json:"quote"`     // unique identifier for the asset used to purchase base
+	Direction Side      `json:"direction"` // trade side
+	Price     float64   `json:"price"`     // amount of quote asset traded for one unit of base asset
+	Volume    float64   `json:"volume"`    // amount of base asset traded
+	Timestamp Timestamp `json:"timestamp"` // trade time
+	PriceUSD  float64   `json:"priceUsd"`  // price of base asset in USD
+}
+
+// Notional returns the traded amount in quote asset.
+func (t *Trade) Notional() float64 {
+	return t.Volume * t.Price
+}
+
+// NotionalUSD returns the traded amount in USD.
+func (t *Trade) NotionalUSD() float64 {
+	return t.Volume * t.PriceUSD
+}
+
+// QuotePriceUSD returns the USD price of the quote asset implied by the trade.
+func (t *Trade) QuotePriceUSD() float64 {
+	if t.Price == 0 {
+		return 0
+	}
+	return t.PriceUSD / t.Price
+}
+
+// IsBuy reports whether the trade is a buy.
+func (t *Trade) IsBuy() bool {
+	return t.Direction == Buy
+}
+
+func (t *Trade) String() string {
+	return t.Exchange + " " + t.Direction.String() + " " +
+		strconv.FormatFloat(t.Volume, 'f', -1, 64) + " " + t.Base + " @ " +
+		strconv.FormatFloat(t.Price, 'f', -1, 64) + " " + t.Quote
+}
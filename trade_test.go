@@ -0,0 +1,40 @@
+package coincap
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestTradeJSON(t *testing.T) {
+	const raw = `{"exchange":"binance","base":"bitcoin","quote":"tether","direction":"sell",` +
+		`"price":20000.5,"volume":0.5,"timestamp":1660000000000,"priceUsd":20010}`
+
+	var tr Trade
+	if err := json.Unmarshal([]byte(raw), &tr); err != nil {
+		t.Fatal(err)
+	}
+	if tr.Direction != Sell || tr.PriceUSD != 20010 || tr.Timestamp != 1660000000000 {
+		t.Fatalf("unexpected trade %+v", tr)
+	}
+	if tr.Notional() != 10000.25 || tr.NotionalUSD() != 10005 {
+		t.Errorf("unexpected notional %v %v", tr.Notional(), tr.NotionalUSD())
+	}
+
+	b, err := json.Marshal(&tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != raw {
+		t.Errorf("got %s\nwant %s", b, raw)
+	}
+
+	if err := json.Unmarshal([]byte(`{"direction":"hold"}`), &tr); err != nil || tr.Direction != UnknownSide {
+		t.Errorf("unknown side must be decoded as UnknownSide, got %v, %v", tr.Direction, err)
+	}
+	if err := json.Unmarshal([]byte(`{"direction":"BUY"}`), &tr); err != nil || tr.Direction != Buy {
+		t.Errorf("expected buy side, got %v, %v", tr.Direction, err)
+	}
+	if _, err := ParseSide("hold"); err == nil {
+		t.Error("ParseSide must reject unknown sides")
+	}
+}
@@ -0,0 +1,195 @@
+package coincap
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// TriangleOptions contains triangular arbitrage detection parameters.
+type TriangleOptions struct {
+	Fee       float64 // fee per conversion in percent
+	Threshold float64 // minimum profit in percent net of fees
+}
+
+// Triangle is a cycle of three conversions within one exchange
+// starting and ending with the same asset.
+type Triangle struct {
+	ExchangeID string
+	Legs       [3]MarketEdge
+	Product    float64 // product of the leg rates
+	Profit     float64 // profit in percent net of fees
+}
+
+// Assets returns the IDs of the assets in the cycle order.
+func (t *Triangle) Assets() [3]string {
+	return [3]string{t.Legs[0].From, t.Legs[1].From, t.Legs[2].From}
+}
+
+// TriangleDetector detects triangular arbitrage opportunities
+// within a single exchange.
+//
+// It is safe for concurrent use.
+type TriangleDetector struct {
+	exchange string
+	opts     TriangleOptions
+
+	mu    sync.Mutex
+	edges map[string]map[string]*MarketEdge // from -> to -> edge
+}
+
+// NewTriangleDetector creates a triangle detector for the exchange.
+// Markets of other exchanges are ignored.
+func NewTriangleDetector(exchangeID string, markets []Market, opts *TriangleOptions) *TriangleDetector {
+	d := &TriangleDetector{
+		exchange: exchangeID,
+		edges:    make(map[string]map[string]*MarketEdge),
+	}
+	if opts != nil {
+		d.opts = *opts
+	}
+	for _, m := range markets {
+		if m.ExchangeID == exchangeID {
+			d.set(m.BaseID, m.QuoteID, m.PriceQuote, m.VolumeUsd24Hr)
+		}
+	}
+	return d
+}
+
+func (d *TriangleDetector) edge(from, to string) *MarketEdge {
+	m, ok := d.edges[from]
+	if !ok {
+		m = make(map[string]*MarketEdge)
+		d.edges[from] = m
+	}
+	e, ok := m[to]
+	if !ok {
+		e = &MarketEdge{From: from, To: to, ExchangeID: d.exchange}
+		m[to] = e
+	}
+	return e
+}
+
+func (d *TriangleDetector) set(base, quote string, price, volume float64) {
+	if price <= 0 || base == "" || quote == "" {
+		return
+	}
+	e := d.edge(base, quote)
+	e.Rate = price
+	if volume != 0 {
+		e.VolumeUsd24Hr = volume
+	}
+
+	e = d.edge(quote, base)
+	e.Rate = 1 / price
+	e.Inverse = true
+	if volume != 0 {
+		e.VolumeUsd24Hr = volume
+	}
+}
+
+// cycle returns the triangle a -> b -> c -> a if all legs exist.
+func (d *TriangleDetector) cycle(a, b, c string) (Triangle, bool) {
+	ab, ok := d.edges[a][b]
+	if !ok {
+		return Triangle{}, false
+	}
+	bc, ok := d.edges[b][c]
+	if !ok {
+		return Triangle{}, false
+	}
+	ca, ok := d.edges[c][a]
+	if !ok {
+		return Triangle{}, false
+	}
+	t := Triangle{
+		ExchangeID: d.exchange,
+		Legs:       [3]MarketEdge{*ab, *bc, *ca},
+		Product:    ab.Rate * bc.Rate * ca.Rate,
+	}
+	net := t.Product * math.Pow(1-d.opts.Fee/100, 3)
+	t.Profit = (net - 1) * 100
+	return t, t.Profit >= d.opts.Threshold
+}
+
+// Update applies the trade price and returns the opportunities
+// involving the traded pair.
+// Trades of other exchanges are ignored.
+func (d *TriangleDetector) Update(t *Trade) []Triangle {
+	if t.Exchange != d.exchange {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.set(t.Base, t.Quote, t.Price, 0)
+
+	var found []Triangle
+	for c := range d.edges[t.Quote] {
+		if c == t.Base {
+			continue
+		}
+		if tr, ok := d.cycle(t.Base, t.Quote, c); ok {
+			found = append(found, tr)
+		}
+		if tr, ok := d.cycle(t.Quote, t.Base, c); ok {
+			found = append(found, tr)
+		}
+	}
+	return found
+}
+
+// Opportunities returns all current opportunities sorted by profit
+// in descending order.
+func (d *TriangleDetector) Opportunities() []Triangle {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var found []Triangle
+	for a, out := range d.edges {
+		for b := range out {
+			if b <= a {
+				continue
+			}
+			for c := range d.edges[b] {
+				if c <= a {
+					continue
+				}
+				if tr, ok := d.cycle(a, b, c); ok {
+					found = append(found, tr)
+				}
+			}
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].Profit > found[j].Profit
+	})
+	return found
+}
+
+// TriangleDetector fetches the exchange markets and creates a triangle detector.
+func (c *Client) TriangleDetector(exchangeID string, opts *TriangleOptions) (*TriangleDetector, error) {
+	markets, err := c.AllMarkets(MarketsRequest{ExchangeID: exchangeID})
+	if err != nil {
+		return nil, err
+	}
+	return NewTriangleDetector(exchangeID, markets, opts), nil
+}
+
+// WatchTriangles streams the triangular arbitrage opportunities
+// detected from the exchange trades.
+func (c *Client) WatchTriangles(detector *TriangleDetector) (*Stream[Triangle], error) {
+	trades, err := c.Trades(detector.exchange)
+	if err != nil {
+		return nil, err
+	}
+
+	return pipe(trades, func(t *Trade, send func(Triangle) bool) bool {
+		for _, tr := range detector.Update(t) {
+			if !send(tr) {
+				return false
+			}
+		}
+		return true
+	}), nil
+}
@@ -0,0 +1,30 @@
+package coincap
+
+import "testing"
+
+func TestTriangleDetector(t *testing.T) {
+	markets := []Market{
+		{ExchangeID: "x", BaseID: "ethereum", QuoteID: "bitcoin", PriceQuote: 0.075},
+		{ExchangeID: "x", BaseID: "bitcoin", QuoteID: "tether", PriceQuote: 20000},
+		{ExchangeID: "x", BaseID: "ethereum", QuoteID: "tether", PriceQuote: 1500},
+		{ExchangeID: "y", BaseID: "ethereum", QuoteID: "tether", PriceQuote: 1},
+	}
+	d := NewTriangleDetector("x", markets, &TriangleOptions{Fee: 0.1, Threshold: 0.5})
+	if ops := d.Opportunities(); len(ops) != 0 {
+		t.Fatalf("expected no opportunities, got %+v", ops)
+	}
+
+	ops := d.Update(&Trade{Exchange: "x", Base: "ethereum", Quote: "tether", Price: 1530})
+	if len(ops) != 1 {
+		t.Fatalf("expected 1 opportunity, got %+v", ops)
+	}
+	if a := ops[0].Assets(); a != [3]string{"ethereum", "tether", "bitcoin"} {
+		t.Errorf("unexpected cycle %v", a)
+	}
+	if !almostEqual(ops[0].Product, 1.02) || ops[0].Profit < 1.6 || ops[0].Profit > 1.8 {
+		t.Errorf("unexpected opportunity %+v", ops[0])
+	}
+	if len(d.Opportunities()) != 1 {
+		t.Error("expected 1 opportunity in full scan")
+	}
+}